@@ -0,0 +1,153 @@
+package fsdb
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var aggregateSyntax = regexp.MustCompile(`^\s*(\w+)\s*\(\s*([^)]*?)\s*\)\s*(?:(?i:as)\s+(\S+))?\s*$`)
+
+type aggregate struct {
+	fn, field, col string
+}
+
+type aggregateGroup struct {
+	vals       []interface{}
+	counts     []int64
+	sums       []float64
+	mins, maxs []interface{}
+}
+
+//	Parses the `aggregates` clause, as already done (for all but placeholders) by `validateStmt`.
+//	Every aggregate's column name must differ from those of the `groupBy` fields and the other aggregates.
+func newAggregates(specs []string, groupBy []string) (aggs []aggregate, err error) {
+	cols := map[string]bool{}
+	for _, fn := range groupBy {
+		if cols[fn] {
+			return nil, stmtErr(ErrInvalidClause, "groupBy", "duplicate field '%s'", fn)
+		}
+		cols[fn] = true
+	}
+	for _, spec := range specs {
+		if parts := aggregateSyntax.FindStringSubmatch(spec); parts == nil {
+			err = stmtErr(ErrInvalidClause, "aggregates", "expected `fn(field)` or `fn(field) as name`, not '%s'", spec)
+		} else if fn := strings.ToLower(parts[1]); fn != "count" && fn != "sum" && fn != "min" && fn != "max" && fn != "avg" {
+			err = stmtErr(ErrInvalidClause, "aggregates", "unknown aggregate function '%s' in '%s'", parts[1], spec)
+		} else if parts[2] == "" || (parts[2] == "*" && fn != "count") {
+			err = stmtErr(ErrInvalidClause, "aggregates", "missing field name in '%s'", spec)
+		} else {
+			agg := aggregate{fn: fn, field: parts[2], col: parts[3]}
+			if agg.col == "" {
+				agg.col = strf("%s(%s)", fn, agg.field)
+			}
+			if !cols[agg.col] {
+				cols[agg.col], aggs = true, append(aggs, agg)
+				continue
+			}
+			err = stmtErr(ErrInvalidClause, "aggregates", "duplicate column name '%s' in '%s'", agg.col, spec)
+		}
+		break
+	}
+	return
+}
+
+func newAggregateGroup(vals []interface{}, numAggs int) *aggregateGroup {
+	return &aggregateGroup{vals: vals, counts: make([]int64, numAggs), sums: make([]float64, numAggs),
+		mins: make([]interface{}, numAggs), maxs: make([]interface{}, numAggs)}
+}
+
+func (me *aggregateGroup) add(rec M, aggs []aggregate) {
+	for i, agg := range aggs {
+		if agg.field == "*" {
+			me.counts[i]++
+		} else if fv := rec.at(agg.field); fv != nil {
+			if agg.fn == "sum" || agg.fn == "avg" {
+				if f, ok := number(fv); ok {
+					me.sums[i] += f
+					me.counts[i]++
+				}
+				continue
+			}
+			me.counts[i]++
+			if me.mins[i] == nil || cmpAny(fv, me.mins[i]) < 0 {
+				me.mins[i] = fv
+			}
+			if me.maxs[i] == nil || cmpAny(fv, me.maxs[i]) > 0 {
+				me.maxs[i] = fv
+			}
+		}
+	}
+}
+
+func (me *aggregateGroup) result(aggs []aggregate) (vals []interface{}) {
+	vals = append(vals, me.vals...)
+	for i, agg := range aggs {
+		switch agg.fn {
+		case "count":
+			vals = append(vals, me.counts[i])
+		case "sum":
+			vals = append(vals, me.sums[i])
+		case "min":
+			vals = append(vals, me.mins[i])
+		case "max":
+			vals = append(vals, me.maxs[i])
+		case "avg":
+			if me.counts[i] == 0 {
+				vals = append(vals, nil)
+			} else {
+				vals = append(vals, me.sums[i]/float64(me.counts[i]))
+			}
+		}
+	}
+	return
+}
+
+//	Groups `recs` by the `groupBy` fields and computes `aggs` per group. The
+//	resulting rows have one column per `groupBy` field and one per aggregate,
+//	sorted by the group values. Without `groupBy`, there's always exactly one row.
+func aggregateRows(recs map[string]M, groupBy []string, aggs []aggregate) *rows {
+	groups := map[string]*aggregateGroup{}
+	if len(groupBy) == 0 {
+		groups[""] = newAggregateGroup(nil, len(aggs))
+	}
+	for _, rec := range recs {
+		vals := make([]interface{}, len(groupBy))
+		keys := make([]string, len(groupBy))
+		for i, fn := range groupBy {
+			vals[i] = rec.at(fn)
+			keys[i] = strf("%T:%v", vals[i], vals[i])
+		}
+		key := strings.Join(keys, "\x00")
+		if groups[key] == nil {
+			groups[key] = newAggregateGroup(vals, len(aggs))
+		}
+		groups[key].add(rec, aggs)
+	}
+	sorted := make([]*aggregateGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		for k := range groupBy {
+			if c := cmpAny(sorted[i].vals[k], sorted[j].vals[k]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	cols := append([]string{}, groupBy...)
+	for _, agg := range aggs {
+		cols = append(cols, agg.col)
+	}
+	res := &rows{rids: make([]string, 0, len(sorted)), recs: make([]M, 0, len(sorted))}
+	for i, g := range sorted {
+		rec := M{}
+		for ci, v := range g.result(aggs) {
+			rec[cols[ci]] = v
+		}
+		res.rids, res.recs = append(res.rids, strf("%d", i)), append(res.recs, rec)
+	}
+	res.project(cols, nil)
+	return res
+}
@@ -0,0 +1,53 @@
+package fsdb_test
+
+import (
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestAggregate(t *testing.T) {
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	execAll(t, db, fsdb.StmtCreateTable("C"),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Alice", "City": "Berlin", "Total": 150}),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Bob", "City": "London", "Total": 50}),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Carl", "City": "Berlin", "Total": 100}),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Dora", "City": "Paris"}))
+
+	for _, test := range []struct {
+		stmt    string
+		want    []row
+		wantErr bool
+	}{
+		{stmt: `{"aggregate": "C", "aggregates": ["count(*)", "sum(Total) as Sum", "avg(Total)"]}`,
+			want: []row{{"count(*)": int64(4), "Sum": 300.0, "avg(Total)": 100.0}}},
+		{stmt: `{"aggregate": "C", "groupBy": ["City"], "aggregates": ["count(*) as N", "min(Name)", "max(Total)"]}`,
+			want: []row{
+				{"City": "Berlin", "N": int64(2), "min(Name)": "Alice", "max(Total)": 150.0},
+				{"City": "London", "N": int64(1), "min(Name)": "Bob", "max(Total)": 50.0},
+				{"City": "Paris", "N": int64(1), "min(Name)": "Dora", "max(Total)": nil},
+			}},
+		{stmt: `{"aggregate": "C", "where": {"City": "Nowhere"}, "aggregates": ["count(*)", "avg(Total)"]}`,
+			want: []row{{"count(*)": int64(0), "avg(Total)": nil}}},
+		{stmt: `{"aggregate": "C", "aggregates": ["median(Total)"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "aggregates": ["sum(*)"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "aggregates": ["count(*"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "groupBy": ["City"], "aggregates": ["count(*) as City"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "aggregates": ["min(Total) as X", "max(Total) as X"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "aggregates": ["count(Name)", "count(Name)"]}`, wantErr: true},
+		{stmt: `{"aggregate": "C", "groupBy": ["City", "City"], "aggregates": ["count(*)"]}`, wantErr: true},
+	} {
+		rows, err := db.Query(test.stmt)
+		if err != nil || test.wantErr {
+			if (err != nil) != test.wantErr {
+				t.Errorf("%s: expected error %v, got %v", test.stmt, test.wantErr, err)
+			} else if err == nil {
+				rows.Close()
+			}
+			continue
+		}
+		expectRows(t, test.stmt, scanAll(t, rows), test.want)
+	}
+}
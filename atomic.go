@@ -0,0 +1,76 @@
+package fsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const tempFileExt = ".tmp"
+
+//	Temp files modified since then may belong to writes still in progress (by any connection or
+//	process), so `enumTempFiles` only reports older ones.
+var processStart = time.Now()
+
+//	Writes `data` to a new temp file next to `filePath`, syncs that to disk, then renames it over
+//	`filePath` and syncs the directory: so `filePath` always holds either its previous or its new
+//	contents in full, never partially written ones. On failure, the temp file is removed again
+//	(unless the process dies first, see `TempFilesError`).
+func writeFileAtomic(filePath string, data []byte) (err error) {
+	var tmp *os.File
+	dir := filepath.Dir(filePath)
+	if tmp, err = ioutil.TempFile(dir, filepath.Base(filePath)+".*"+tempFileExt); err == nil {
+		perm := os.FileMode(0644)
+		if fi, e := os.Stat(filePath); e == nil {
+			perm = fi.Mode().Perm()
+		}
+		if _, err = tmp.Write(data); err == nil {
+			if err = tmp.Chmod(perm); err == nil {
+				err = tmp.Sync()
+			}
+		}
+		if e := tmp.Close(); err == nil {
+			err = e
+		}
+		if err == nil {
+			err = os.Rename(tmp.Name(), filePath)
+		}
+		if err != nil {
+			os.Remove(tmp.Name())
+		} else if d, e := os.Open(dir); e == nil {
+			// not supported everywhere (such as on Windows), and the rename did succeed: so errors are ignored
+			d.Sync()
+			d.Close()
+		}
+	}
+	return
+}
+
+//	Returns the temp files left behind in `me.dir` by interrupted `writeFileAtomic` calls for table,
+//	".meta" or record files (the latter in the directories of tables stored as one file per record),
+//	ignoring those modified since `processStart`.
+func (me *conn) enumTempFiles() (filePaths []string, err error) {
+	return enumTempFilesIn(me.dir, me.drv.fileExt, true)
+}
+
+func enumTempFilesIn(dir, fileExt string, tableDirs bool) (filePaths []string, err error) {
+	var (
+		fis []os.FileInfo
+		sub []string
+	)
+	if fis, err = ioutil.ReadDir(dir); err == nil {
+		for _, fi := range fis {
+			if fn := fi.Name(); fi.IsDir() && tableDirs && strings.HasSuffix(fn, fileExt) {
+				if sub, err = enumTempFilesIn(filepath.Join(dir, fn), fileExt, false); err != nil {
+					return
+				}
+				filePaths = append(filePaths, sub...)
+			} else if !fi.IsDir() && strings.HasSuffix(fn, tempFileExt) && strings.Contains(fn, fileExt+".") && fi.ModTime().Before(processStart) {
+				filePaths = append(filePaths, filepath.Join(dir, fn))
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package fsdb_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+//	Leftover temp files must be reported, but must not keep the database from being used.
+func TestLeftoverTempFiles(t *testing.T) {
+	var reported []string
+	fsdb.OnLeftoverTempFiles = func(err *fsdb.TempFilesError) { reported = append(reported, err.Paths...) }
+	defer func() { fsdb.OnLeftoverTempFiles = nil }()
+
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	old, fresh := filepath.Join(dir, "T"+jsondb.FileExt+".123.tmp"), filepath.Join(dir, "T"+jsondb.FileExt+".456.tmp")
+	for _, filePath := range []string{old, fresh} {
+		if err := ioutil.WriteFile(filePath, []byte(`{"0":`), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for filePath, modTime := range map[string]time.Time{old: time.Now().Add(-time.Hour), fresh: time.Now().Add(time.Minute)} {
+		if err := os.Chtimes(filePath, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	execAll(t, db, fsdb.StmtCreateTable("T"), fsdb.StmtInsertInto("T", fsdb.M{"A": 1}))
+	if !reflect.DeepEqual(reported, []string{old}) {
+		t.Fatalf("expected only %s to be reported, got %v", old, reported)
+	} else if err := (&fsdb.TempFilesError{Paths: reported}).Remove(); err != nil {
+		t.Fatal(err)
+	} else if _, err = os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, got %v", old, err)
+	} else if _, err = os.Stat(fresh); err != nil {
+		t.Errorf("expected %s to be kept, got %v", fresh, err)
+	}
+}
@@ -0,0 +1,494 @@
+package fsdb
+
+//	Sort direction for `SelectStmt.OrderBy`.
+type Order string
+
+const (
+	Asc  Order = "asc"
+	Desc Order = "desc"
+)
+
+//	The common base of all statement builders such as `SelectStmt`: records the
+//	first invalid clause or combination of clauses, to be reported by `Build`.
+type stmtBuilder struct {
+	cmd, name string
+	clauses   M
+	err       error
+}
+
+func newStmtBuilder(cmd, name string) (me stmtBuilder) {
+	me = stmtBuilder{cmd: cmd, name: name, clauses: M{}}
+	if name == "" {
+		me.fail("no table name given")
+	}
+	return
+}
+
+func (me *stmtBuilder) fail(format string, args ...interface{}) {
+	if me.err == nil {
+		me.err = me.invalid(format, args...)
+	}
+}
+
+func (me *stmtBuilder) invalid(format string, args ...interface{}) error {
+	return errf("Invalid %s statement: %s", me.cmd, strf(format, args...))
+}
+
+func (me *stmtBuilder) where(where M) {
+	if len(where) > 0 {
+		if prev := m(me.clauses["where"]); prev == nil {
+			me.clauses["where"] = where
+		} else {
+			me.clauses["where"] = M{opAnd: []interface{}{prev, where}}
+		}
+	}
+}
+
+//	No `fields` means all fields (`returning: true`), else they're appended to any previous ones.
+func (me *stmtBuilder) returning(fields []string) {
+	if len(fields) == 0 {
+		me.clauses["returning"] = true
+	} else {
+		prev, _ := me.clauses["returning"].([]string)
+		me.clauses["returning"] = append(prev, fields...)
+	}
+}
+
+func (me *stmtBuilder) set(set M) {
+	if len(set) == 0 {
+		me.fail("empty set")
+	} else {
+		all := m(me.clauses["set"])
+		if all == nil {
+			all = M{}
+		}
+		for fn, fv := range set {
+			all[fn] = fv
+		}
+		me.clauses["set"] = all
+	}
+}
+
+//	Returns the JSON statement, or the first error recorded while building it.
+func (me *stmtBuilder) Build() (stmt string, err error) {
+	if set, err := m(me.clauses["set"]), me.err; err == nil {
+		switch me.cmd {
+		case cmdInsertInto, cmdUpdateWhere, cmdUpsertInto:
+			if set == nil {
+				return "", me.invalid("no set")
+			} else if _, hasId := set[IdField]; me.cmd == cmdUpsertInto && !hasId && me.clauses["on"] == nil {
+				return "", me.invalid("neither %s in set nor on fields", IdField)
+			}
+		case cmdAggregate:
+			if me.clauses["aggregates"] == nil {
+				return "", me.invalid("no aggregates")
+			}
+		case cmdRenameTable:
+			if me.clauses["to"] == nil {
+				return "", me.invalid("no new table name")
+			}
+		case cmdAlterTable:
+			if me.clauses["renameFields"] == nil && me.clauses["dropFields"] == nil && me.clauses["addFields"] == nil {
+				return "", me.invalid("no fields to rename, drop or add")
+			}
+		}
+	}
+	if err = me.err; err == nil {
+		query := M{me.cmd: me.name}
+		for k, v := range me.clauses {
+			query[k] = v
+		}
+		if _, _, err = validateStmt(query); err == nil {
+			stmt, err = marshalStmt(query)
+		}
+	}
+	return
+}
+
+//	Returns the JSON statement, like `Build`, but panics if it's invalid.
+func (me *stmtBuilder) MustBuild() string {
+	stmt, err := me.Build()
+	if err != nil {
+		panic(err)
+	}
+	return stmt
+}
+
+//	Implements `fmt.Stringer`: returns the JSON statement or, if it's invalid, a description
+//	of why (that is not a valid statement either). Never panics, unlike `MustBuild`.
+func (me *stmtBuilder) String() string {
+	stmt, err := me.Build()
+	if err != nil {
+		return strf("<invalid statement: %s>", err.Error())
+	}
+	return stmt
+}
+
+//	Builds a statement that takes no clauses, such as `dropTable` or `truncateTable`.
+type TableStmt struct{ stmtBuilder }
+
+//	Builds a `createTable` statement.
+type CreateTableStmt struct{ stmtBuilder }
+
+//	Starts building a `createTable` statement.
+func CreateTable(name string) *CreateTableStmt {
+	return &CreateTableStmt{newStmtBuilder(cmdCreateTable, name)}
+}
+
+//	Specifies how new record `__id`s are generated, see `StmtCreateTableIds`.
+func (me *CreateTableStmt) Ids(ids IdStrategy) *CreateTableStmt {
+	me.clauses["idStrategy"] = string(ids)
+	return me
+}
+
+//	Starts building a `dropTable` statement.
+func DropTable(name string) *TableStmt {
+	return &TableStmt{newStmtBuilder(cmdDropTable, name)}
+}
+
+//	Starts building a `truncateTable` statement.
+func TruncateTable(name string) *TableStmt {
+	return &TableStmt{newStmtBuilder(cmdTruncateTable, name)}
+}
+
+//	Starts building a `listTables` statement (see `StmtListTables`).
+func ListTables(pattern string) *TableStmt {
+	return &TableStmt{newStmtBuilder(cmdListTables, pattern)}
+}
+
+//	Starts building a `describeTable` statement (see `StmtDescribeTable`).
+func DescribeTable(name string) *TableStmt {
+	return &TableStmt{newStmtBuilder(cmdDescribeTable, name)}
+}
+
+//	Builds a `renameTable` statement.
+type RenameTableStmt struct{ stmtBuilder }
+
+//	Starts building a `renameTable` statement. Requires `To`.
+func RenameTable(name string) *RenameTableStmt {
+	return &RenameTableStmt{newStmtBuilder(cmdRenameTable, name)}
+}
+
+//	Specifies the new table name.
+func (me *RenameTableStmt) To(newName string) *RenameTableStmt {
+	if newName == "" {
+		me.fail("empty new table name")
+	}
+	me.clauses["to"] = newName
+	return me
+}
+
+//	Builds an `alterTable` statement.
+type AlterTableStmt struct{ stmtBuilder }
+
+//	Starts building an `alterTable` statement (see `StmtAlterTable`). Requires
+//	at least one of `RenameField`, `DropField` or `AddField`.
+func AlterTable(name string) *AlterTableStmt {
+	return &AlterTableStmt{newStmtBuilder(cmdAlterTable, name)}
+}
+
+//	Renames the field `oldName` to `newName` in every record.
+func (me *AlterTableStmt) RenameField(oldName, newName string) *AlterTableStmt {
+	if oldName == "" || newName == "" {
+		me.fail("empty field name to rename")
+	}
+	me.fieldsClause("renameFields")[oldName] = newName
+	return me
+}
+
+//	Removes the `fields` from every record.
+func (me *AlterTableStmt) DropField(fields ...string) *AlterTableStmt {
+	me.clauses["dropFields"] = append(strs(me.clauses["dropFields"]), fields...)
+	return me
+}
+
+//	Adds `field` with the `defaultValue` to every record that lacks it.
+func (me *AlterTableStmt) AddField(field string, defaultValue interface{}) *AlterTableStmt {
+	if field == "" {
+		me.fail("empty field name to add")
+	}
+	me.fieldsClause("addFields")[field] = defaultValue
+	return me
+}
+
+func (me *AlterTableStmt) fieldsClause(key string) (fields M) {
+	if fields = m(me.clauses[key]); fields == nil {
+		fields = M{}
+		me.clauses[key] = fields
+	}
+	return
+}
+
+//	Builds an `insertInto` statement.
+type InsertStmt struct{ stmtBuilder }
+
+//	Starts building an `insertInto` statement. Requires `Set`.
+func InsertInto(name string) *InsertStmt {
+	return &InsertStmt{newStmtBuilder(cmdInsertInto, name)}
+}
+
+//	Adds the fields of `rec` to the record to insert.
+func (me *InsertStmt) Set(rec M) *InsertStmt {
+	me.set(rec)
+	return me
+}
+
+//	Has the statement, when run via `Query`, return the inserted record with the specified
+//	`fields`, or with all of them (and its `__id`) if none are specified.
+func (me *InsertStmt) Returning(fields ...string) *InsertStmt {
+	me.returning(fields)
+	return me
+}
+
+//	Builds a `selectFrom` statement.
+type SelectStmt struct{ stmtBuilder }
+
+//	Starts building a `selectFrom` statement.
+func Select(name string) *SelectStmt {
+	return &SelectStmt{newStmtBuilder(cmdSelectFrom, name)}
+}
+
+//	Adds `where` criteria. Multiple calls are `AND`-ed together.
+func (me *SelectStmt) Where(where M) *SelectStmt {
+	me.where(where)
+	return me
+}
+
+//	Specifies the exact result columns. Multiple calls are appended.
+func (me *SelectStmt) Fields(fields ...string) *SelectStmt {
+	me.clauses["fields"] = append(strs(me.clauses["fields"]), fields...)
+	return me
+}
+
+//	Returns the unique combinations of values of the `fields` (see `StmtSelectFromDistinct`)
+//	rather than records. Multiple calls are appended. Cannot be combined with `Fields`.
+func (me *SelectStmt) Distinct(fields ...string) *SelectStmt {
+	me.clauses["distinct"] = append(strs(me.clauses["distinct"]), fields...)
+	return me
+}
+
+//	Specifies result columns to omit. Multiple calls are appended.
+func (me *SelectStmt) Exclude(fields ...string) *SelectStmt {
+	me.clauses["exclude"] = append(strs(me.clauses["exclude"]), fields...)
+	return me
+}
+
+//	Adds a sort key. Multiple calls add further keys for records that are equal on all previous ones.
+func (me *SelectStmt) OrderBy(field string, order Order) *SelectStmt {
+	if field == "" {
+		me.fail("empty orderBy field")
+	} else if order != Asc && order != Desc {
+		me.fail("invalid order '%s' for orderBy field '%s'", order, field)
+	}
+	me.clauses["orderBy"] = append(strs(me.clauses["orderBy"]), field+" "+string(order))
+	return me
+}
+
+//	Returns at most `n` records. Must be positive.
+func (me *SelectStmt) Limit(n int) *SelectStmt {
+	if n <= 0 {
+		me.fail("limit must be positive, not %d", n)
+	}
+	me.clauses["limit"] = n
+	return me
+}
+
+//	Skips the first `n` records. Must not be negative.
+func (me *SelectStmt) Offset(n int) *SelectStmt {
+	if n < 0 {
+		me.fail("offset must not be negative, not %d", n)
+	}
+	me.clauses["offset"] = n
+	return me
+}
+
+//	Adds an inner join (see `StmtSelectFromJoin`) of the records in `table` whose `foreign` field
+//	equals the `local` field, prefixing their fields with `as`. Empty `foreign` and `as` mean
+//	`__id` and `table`, respectively.
+func (me *SelectStmt) Join(table, local, foreign, as string) *SelectStmt {
+	return me.join(table, local, foreign, as, false)
+}
+
+//	Like `Join`, but keeps records without any match.
+func (me *SelectStmt) LeftJoin(table, local, foreign, as string) *SelectStmt {
+	return me.join(table, local, foreign, as, true)
+}
+
+func (me *SelectStmt) join(table, local, foreign, as string, left bool) *SelectStmt {
+	if table == "" || local == "" {
+		me.fail("join needs both a table and a local field")
+	}
+	j := M{"table": table, "local": local}
+	if foreign != "" {
+		j["foreign"] = foreign
+	}
+	if as != "" {
+		j["as"] = as
+	}
+	if left {
+		j["left"] = true
+	}
+	me.clauses["join"] = append(interfaces(me.clauses["join"]), j)
+	return me
+}
+
+//	Returns the statement as a subquery, for use as a value in the `where` criteria of another
+//	statement: it stands for the values of `field` (or `__id` if empty) of the records selected.
+//	Like `MustBuild`, panics if the statement is invalid.
+func (me *SelectStmt) Subquery(field string) (sub M) {
+	if _, err := me.Build(); err != nil {
+		panic(err)
+	}
+	sub = M{cmdSelectFrom: me.name}
+	for k, v := range me.clauses {
+		sub[k] = v
+	}
+	if field != "" {
+		sub["field"] = field
+	}
+	return
+}
+
+//	Builds an `updateWhere` statement.
+type UpdateStmt struct{ stmtBuilder }
+
+//	Starts building an `updateWhere` statement. Requires `Set`.
+func Update(name string) *UpdateStmt {
+	return &UpdateStmt{newStmtBuilder(cmdUpdateWhere, name)}
+}
+
+//	Adds fields or update operators to `set`, see `StmtUpdateWhere`.
+func (me *UpdateStmt) Set(set M) *UpdateStmt {
+	me.set(set)
+	return me
+}
+
+//	Adds `where` criteria. Multiple calls are `AND`-ed together.
+func (me *UpdateStmt) Where(where M) *UpdateStmt {
+	me.where(where)
+	return me
+}
+
+//	Has the statement, when run via `Query`, return the updated records (as updated) with the
+//	specified `fields`, or with all of them (and their `__id`) if none are specified.
+func (me *UpdateStmt) Returning(fields ...string) *UpdateStmt {
+	me.returning(fields)
+	return me
+}
+
+//	Builds a `deleteFrom` statement.
+type DeleteStmt struct{ stmtBuilder }
+
+//	Starts building a `deleteFrom` statement.
+func DeleteFrom(name string) *DeleteStmt {
+	return &DeleteStmt{newStmtBuilder(cmdDeleteFrom, name)}
+}
+
+//	Adds `where` criteria. Multiple calls are `AND`-ed together.
+func (me *DeleteStmt) Where(where M) *DeleteStmt {
+	me.where(where)
+	return me
+}
+
+//	Has the statement, when run via `Query`, return the deleted records with the specified
+//	`fields`, or with all of them (and their `__id`) if none are specified.
+func (me *DeleteStmt) Returning(fields ...string) *DeleteStmt {
+	me.returning(fields)
+	return me
+}
+
+//	Builds a `count` or `exists` statement.
+type CountStmt struct{ stmtBuilder }
+
+//	Starts building a `count` statement (see `StmtCount`).
+func Count(name string) *CountStmt {
+	return &CountStmt{newStmtBuilder(cmdCount, name)}
+}
+
+//	Starts building an `exists` statement (see `StmtExists`).
+func Exists(name string) *CountStmt {
+	return &CountStmt{newStmtBuilder(cmdExists, name)}
+}
+
+//	Adds `where` criteria. Multiple calls are `AND`-ed together.
+func (me *CountStmt) Where(where M) *CountStmt {
+	me.where(where)
+	return me
+}
+
+//	Builds an `upsertInto` statement.
+type UpsertStmt struct{ stmtBuilder }
+
+//	Starts building an `upsertInto` statement. Requires `Set`, and either `On` or an `__id` in `Set`.
+func UpsertInto(name string) *UpsertStmt {
+	return &UpsertStmt{newStmtBuilder(cmdUpsertInto, name)}
+}
+
+//	Adds fields or update operators to the record to upsert, see `StmtUpsertInto`.
+func (me *UpsertStmt) Set(rec M) *UpsertStmt {
+	me.set(rec)
+	return me
+}
+
+//	Specifies the fields identifying the records to update. Multiple calls are appended.
+func (me *UpsertStmt) On(fields ...string) *UpsertStmt {
+	me.clauses["on"] = append(strs(me.clauses["on"]), fields...)
+	return me
+}
+
+//	Builds an `aggregate` statement.
+type AggregateStmt struct{ stmtBuilder }
+
+//	Starts building an `aggregate` statement. Requires at least one of `Count`, `Sum`, `Min`, `Max` or `Avg`.
+func Aggregate(name string) *AggregateStmt {
+	return &AggregateStmt{newStmtBuilder(cmdAggregate, name)}
+}
+
+//	Adds `where` criteria. Multiple calls are `AND`-ed together.
+func (me *AggregateStmt) Where(where M) *AggregateStmt {
+	me.where(where)
+	return me
+}
+
+//	Adds fields to group by. Multiple calls are appended.
+func (me *AggregateStmt) GroupBy(fields ...string) *AggregateStmt {
+	me.clauses["groupBy"] = append(strs(me.clauses["groupBy"]), fields...)
+	return me
+}
+
+//	Counts the records having `field`, or all records if it's `*`. The column is named `as` if not empty.
+func (me *AggregateStmt) Count(field, as string) *AggregateStmt {
+	return me.agg("count", field, as)
+}
+
+//	Sums up the numbers in `field`. The column is named `as` if not empty.
+func (me *AggregateStmt) Sum(field, as string) *AggregateStmt {
+	return me.agg("sum", field, as)
+}
+
+//	Finds the lowest value of `field`. The column is named `as` if not empty.
+func (me *AggregateStmt) Min(field, as string) *AggregateStmt {
+	return me.agg("min", field, as)
+}
+
+//	Finds the highest value of `field`. The column is named `as` if not empty.
+func (me *AggregateStmt) Max(field, as string) *AggregateStmt {
+	return me.agg("max", field, as)
+}
+
+//	Averages the numbers in `field`. The column is named `as` if not empty.
+func (me *AggregateStmt) Avg(field, as string) *AggregateStmt {
+	return me.agg("avg", field, as)
+}
+
+func (me *AggregateStmt) agg(fn, field, as string) *AggregateStmt {
+	spec := strf("%s(%s)", fn, field)
+	if as != "" {
+		spec += " as " + as
+	}
+	if _, err := newAggregates([]string{spec}, nil); err != nil {
+		me.fail("%s", err.Error())
+	}
+	me.clauses["aggregates"] = append(strs(me.clauses["aggregates"]), spec)
+	return me
+}
@@ -0,0 +1,71 @@
+package fsdb_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+)
+
+type builder interface {
+	Build() (string, error)
+	MustBuild() string
+	String() string
+}
+
+func TestBuilders(t *testing.T) {
+	for i, test := range []struct {
+		stmt builder
+		want string
+	}{
+		{fsdb.CreateTable("T"), `{"createTable":"T"}`},
+		{fsdb.DropTable("T"), `{"dropTable":"T"}`},
+		{fsdb.InsertInto("T").Set(fsdb.M{"A": 1}).Set(fsdb.M{"B": "$1", "C": fsdb.M{"$param": 1}}),
+			`{"insertInto":"T","set":{"A":1,"B":"$$1","C":{"$param":1}}}`},
+		{fsdb.Select("T"), `{"selectFrom":"T"}`},
+		{fsdb.Select("T").Where(fsdb.M{"A": 1}).Where(fsdb.M{"B": 2}).Fields("A", "B").OrderBy("A", fsdb.Desc).OrderBy("B", fsdb.Asc).Limit(10).Offset(5),
+			`{"fields":["A","B"],"limit":10,"offset":5,"orderBy":["A desc","B asc"],"selectFrom":"T","where":{"$and":[{"A":1},{"B":2}]}}`},
+		{fsdb.Select("T").Exclude("X").Join("U", "UId", "", "u").LeftJoin("V", "VId", "Key", ""),
+			`{"exclude":["X"],"join":[{"as":"u","local":"UId","table":"U"},{"foreign":"Key","left":true,"local":"VId","table":"V"}],"selectFrom":"T"}`},
+		{fsdb.Update("T").Set(fsdb.M{"A": 1}).Where(fsdb.M{"B": 2}), `{"set":{"A":1},"updateWhere":"T","where":{"B":2}}`},
+		{fsdb.DeleteFrom("T").Where(fsdb.M{"B": 2}), `{"deleteFrom":"T","where":{"B":2}}`},
+		{fsdb.UpsertInto("T").Set(fsdb.M{"A": 1}).On("A"), `{"on":["A"],"set":{"A":1},"upsertInto":"T"}`},
+		{fsdb.UpsertInto("T").Set(fsdb.M{"__id": 1}), `{"set":{"__id":1},"upsertInto":"T"}`},
+		{fsdb.Aggregate("T").GroupBy("G").Count("*", "N").Sum("A", "").Min("A", "").Max("A", "").Avg("A", "Mean"),
+			`{"aggregate":"T","aggregates":["count(*) as N","sum(A)","min(A)","max(A)","avg(A) as Mean"],"groupBy":["G"]}`},
+
+		{fsdb.CreateTable(""), ""},
+		{fsdb.InsertInto("T"), ""},
+		{fsdb.InsertInto("T").Set(fsdb.M{}), ""},
+		{fsdb.Update("T").Where(fsdb.M{"B": 2}), ""},
+		{fsdb.UpsertInto("T").Set(fsdb.M{"A": 1}), ""},
+		{fsdb.Select("T").OrderBy("", fsdb.Asc), ""},
+		{fsdb.Select("T").OrderBy("A", "up"), ""},
+		{fsdb.Select("T").Limit(0), ""},
+		{fsdb.Select("T").Offset(-1), ""},
+		{fsdb.Select("T").Join("", "UId", "", ""), ""},
+		{fsdb.Aggregate("T"), ""},
+		{fsdb.Aggregate("T").Sum("*", ""), ""},
+	} {
+		stmt, err := test.stmt.Build()
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("%d: expected an error, got %s", i, stmt)
+			} else if s := test.stmt.String(); !strings.HasPrefix(s, "<invalid statement: ") {
+				t.Errorf("%d: expected String to describe the error, got %s", i, s)
+			} else if !panics(func() { test.stmt.MustBuild() }) {
+				t.Errorf("%d: expected MustBuild to panic", i)
+			}
+		} else if err != nil {
+			t.Errorf("%d: expected %s, got %v", i, test.want, err)
+		} else if stmt != test.want || test.stmt.String() != test.want || test.stmt.MustBuild() != test.want {
+			t.Errorf("%d: expected %s, got %s", i, test.want, stmt)
+		}
+	}
+}
+
+func panics(f func()) (didPanic bool) {
+	defer func() { didPanic = recover() != nil }()
+	f()
+	return
+}
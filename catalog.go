@@ -0,0 +1,116 @@
+package fsdb
+
+import (
+	"database/sql/driver"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+var (
+	listTablesCols    = []string{"name", "records", "fileSize", "modified", "lastLoad"}
+	describeTableCols = []string{"records", "fileSize", "modified", "lastLoad", "field", "type", "count"}
+)
+
+//	Returns the table-level columns (all of `listTablesCols`) for `me`.
+func (me *table) stats() (rec M, err error) {
+	var fi os.FileInfo
+	if err = me.reload(true); err == nil {
+		if fi, err = os.Stat(me.filePath); err == nil {
+			size := fi.Size()
+			if fi.IsDir() {
+				size = me.recordFilesSize()
+			}
+			defer me.UnlockIf(me.LockIf(me.shouldLock()))
+			rec = M{"name": me.name, "records": int64(len(me.recs)), "fileSize": size, "modified": fi.ModTime(), "lastLoad": me.lastLoad}
+		}
+	}
+	return
+}
+
+//	Counts, per field, how many records have a value of which Go type (`%T`).
+func (me *table) fieldTypes() (counts map[string]map[string]int64) {
+	defer me.UnlockIf(me.LockIf(me.shouldLock()))
+	counts = map[string]map[string]int64{}
+	for _, rix := range me.recs {
+		for fn, fv := range m(rix) {
+			if counts[fn] == nil {
+				counts[fn] = map[string]int64{}
+			}
+			counts[fn][strf("%T", fv)]++
+		}
+	}
+	return
+}
+
+//	Lists all tables on disk whose name matches the `filepath.Match` pattern, sorted by name.
+func (me *conn) doListTables(pattern string) (res driver.Rows, err error) {
+	var (
+		tableNames []string
+		errs       []error
+		t          *table
+		rec        M
+	)
+	if _, err = filepath.Match(pattern, ""); err != nil {
+		err = stmtErr(ErrInvalidClause, cmdListTables, "%s", err.Error())
+	} else if tableNames, errs = me.enumTableFiles(); len(errs) > 0 {
+		err = errs[0]
+	} else {
+		sort.Strings(tableNames)
+		list := &rows{}
+		for _, tn := range tableNames {
+			if ok, _ := filepath.Match(pattern, tn); ok {
+				if t, err = me.tables.get(tn); err == nil {
+					rec, err = t.stats()
+				}
+				if err != nil {
+					return
+				}
+				list.rids, list.recs = append(list.rids, strf("%d", len(list.recs))), append(list.recs, rec)
+			}
+		}
+		list.project(listTablesCols, nil)
+		res = list
+	}
+	return
+}
+
+//	One row per field and observed Go type, sorted by both. The table-level columns repeat in every
+//	row; for a table without any fields, there's just one row with only those.
+func (me *conn) doDescribeTable(name string) (res driver.Rows, err error) {
+	var (
+		t     *table
+		stats M
+	)
+	if t, err = me.tables.get(name); err == nil {
+		if stats, err = t.stats(); err == nil {
+			desc := &rows{}
+			counts := t.fieldTypes()
+			fields := make([]string, 0, len(counts))
+			for fn, _ := range counts {
+				fields = append(fields, fn)
+			}
+			sort.Strings(fields)
+			for _, fn := range fields {
+				types := make([]string, 0, len(counts[fn]))
+				for tn, _ := range counts[fn] {
+					types = append(types, tn)
+				}
+				sort.Strings(types)
+				for _, tn := range types {
+					rec := M{"field": fn, "type": tn, "count": counts[fn][tn]}
+					for k, v := range stats {
+						rec[k] = v
+					}
+					desc.rids, desc.recs = append(desc.rids, strf("%d", len(desc.recs))), append(desc.recs, rec)
+				}
+			}
+			if len(desc.recs) == 0 {
+				desc.rids, desc.recs = []string{"0"}, []M{stats}
+			}
+			desc.project(describeTableCols, nil)
+			res = desc
+		}
+	}
+	return
+}
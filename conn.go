@@ -2,14 +2,17 @@ package fsdb
 
 import (
 	"database/sql/driver"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/metaleap/go-util/fs"
+	"github.com/metaleap/go-util/run"
 )
 
 type conn struct {
+	urun.MutexIf
 	drv    *drv
 	tx     *tx
 	dir    string
@@ -34,25 +37,65 @@ func (me *conn) Close() (err error) {
 	return
 }
 
+//	Returns the names of all tables in `me.dir`: stored either as one file or as a directory of record files.
 func (me *conn) enumTableFiles() (tableNames []string, errs []error) {
-	errs = ufs.WalkFilesIn(me.dir, func(filePath string) bool {
-		if strings.HasSuffix(filePath, me.drv.fileExt) {
-			fn := filepath.Base(filePath)
+	fis, err := ioutil.ReadDir(me.dir)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	for _, fi := range fis {
+		if fn := fi.Name(); strings.HasSuffix(fn, me.drv.fileExt) {
 			tableNames = append(tableNames, fn[:len(fn)-len(me.drv.fileExt)])
 		}
-		return true
-	})
+	}
+	return
+}
+
+func (me *conn) doAggregate(name string, where, groupBy, aggregates interface{}) (res driver.Rows, err error) {
+	var (
+		t    *table
+		aggs []aggregate
+	)
+	if aggs, err = newAggregates(strs(aggregates), strs(groupBy)); err == nil {
+		if t, err = me.tables.get(name); err == nil {
+			var recs map[string]M
+			if recs, err = t.find(m(where)); err == nil {
+				res = aggregateRows(recs, strs(groupBy), aggs)
+			}
+		}
+	}
+	return
+}
+
+func (me *conn) doAlterTable(name string, renameFields, dropFields, addFields interface{}) (res driver.Result, err error) {
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res, err = t.alter(m(renameFields), strs(dropFields), m(addFields))
+	}
 	return
 }
 
-func (me *conn) doCreateTable(name string) (err error) {
+func (me *conn) doCount(name string, where interface{}) (res driver.Rows, err error) {
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res = singleRow(cmdCount, t.count(m(where), 0))
+	}
+	return
+}
+
+func (me *conn) doCreateTable(name string, idStrategy interface{}) (err error) {
+	var created bool
 	if _, ok := me.tables.all[name]; !ok {
-		if fp := filepath.Join(me.dir, name+me.drv.fileExt); ufs.FileExists(fp) {
+		if fp := filepath.Join(me.dir, name+me.drv.fileExt); ufs.FileExists(fp) || ufs.DirExists(fp) {
 			err = errf("Cannot create table '%s': already exists", name)
+		} else if me.drv.tableOptions(name).FilePerRecord {
+			err = os.Mkdir(fp, 0755)
+			created = err == nil
 		} else {
 			var data []byte
 			if data, err = me.drv.marshal(M{}); err == nil {
-				err = ufs.WriteBinaryFile(fp, data)
+				err = writeFileAtomic(fp, data)
+				created = err == nil
 			} else {
 				println(err.Error())
 				panic(err)
@@ -60,7 +103,13 @@ func (me *conn) doCreateTable(name string) (err error) {
 		}
 	}
 	if err == nil {
-		_, err = me.tables.get(name)
+		var t *table
+		if t, err = me.tables.get(name); err == nil && created {
+			if ids, _ := idStrategy.(string); ids != "" {
+				t.meta.IdStrategy = IdStrategy(ids)
+			}
+			err = t.persistMeta()
+		}
 	}
 	return
 }
@@ -69,7 +118,7 @@ func (me *conn) doDeleteFrom(name string, where interface{}) (res driver.Result,
 	var t *table
 	if t, err = me.tables.get(name); err == nil {
 		var recs map[string]M
-		if recs, err = t.fetch(m(where)); err == nil {
+		if recs, err = t.find(m(where)); err == nil {
 			rids := make([]string, 0, len(recs))
 			for rid, _ := range recs {
 				rids = append(rids, rid)
@@ -81,12 +130,32 @@ func (me *conn) doDeleteFrom(name string, where interface{}) (res driver.Result,
 }
 
 func (me *conn) doDropTable(name string) (err error) {
-	t := me.tables.all[name]
+	fp := filepath.Join(me.dir, name+me.drv.fileExt)
+	if t := me.tables.all[name]; t != nil {
+		fp = t.filePath
+	}
 	delete(me.tables.all, name)
-	if t != nil {
-		err = os.Remove(t.filePath)
-	} else {
-		err = os.Remove(filepath.Join(me.dir, name+me.drv.fileExt))
+	if ufs.DirExists(fp) {
+		err = os.RemoveAll(fp)
+	} else if err = os.Remove(fp); os.IsNotExist(err) {
+		err = stmtErr(ErrNoSuchTable, name, "")
+	}
+	if err == nil {
+		for _, sidecar := range sidecarFilePaths(fp) {
+			if err = os.Remove(sidecar); os.IsNotExist(err) {
+				err = nil
+			} else if err != nil {
+				break
+			}
+		}
+	}
+	return
+}
+
+func (me *conn) doExists(name string, where interface{}) (res driver.Rows, err error) {
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res = singleRow(cmdExists, t.count(m(where), 1) > 0)
 	}
 	return
 }
@@ -99,41 +168,59 @@ func (me *conn) doInsertInto(name string, rec interface{}) (res driver.Result, e
 	return
 }
 
-func (me *conn) doSelectFrom(name string, where interface{}) (res driver.Rows, err error) {
+func (me *conn) doRenameTable(name string, newName interface{}) (err error) {
+	if to, _ := newName.(string); !isFileName(to) {
+		err = stmtErr(ErrInvalidClause, "to", "expected a table name, not %#v", newName)
+	} else {
+		err = me.tables.rename(name, to)
+	}
+	return
+}
+
+func (me *conn) doSelectFrom(name string, q *selectQuery) (res driver.Rows, err error) {
 	var t *table
 	if t, err = me.tables.get(name); err == nil {
 		var recs map[string]M
-		if recs, err = t.fetch(m(where)); err == nil {
-			res = newRows(recs)
+		if recs, err = t.find(q.where); err == nil {
+			rows := newRows(recs)
+			for _, j := range q.joins {
+				if err = rows.join(me, j); err != nil {
+					return
+				}
+			}
+			if len(q.distinct) > 0 {
+				rows.distinct(q.distinct)
+				q.fields = q.distinct
+			}
+			rows.orderBy(q.orderBy)
+			rows.page(q.limit, q.offset)
+			rows.project(q.fields, q.exclude)
+			res = rows
 		}
 	}
 	return
 }
 
+func (me *conn) doTruncateTable(name string) (res driver.Result, err error) {
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res, err = t.truncate()
+	}
+	return
+}
+
 func (me *conn) doUpdateWhere(name string, set, where interface{}) (res driver.Result, err error) {
-	var (
-		t   *table
-		num int64
-	)
-	upd := m(set)
-	if t, err = me.tables.get(name); err == nil && len(upd) > 0 {
-		if err = t.reload(true); err == nil {
-			var recs map[string]M
-			if recs, err = t.fetch(m(where)); err == nil {
-				for _, rec := range recs {
-					for fn, fv := range upd {
-						rec[fn] = fv
-					}
-					num++
-				}
-				if num > 0 {
-					err = t.persist()
-				}
-			}
-		}
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res, err = t.update(m(set), m(where))
 	}
-	if err == nil {
-		res = &result{AffectedRows: num}
+	return
+}
+
+func (me *conn) doUpsertInto(name string, rec, on interface{}) (res driver.Result, err error) {
+	var t *table
+	if t, err = me.tables.get(name); err == nil {
+		res, err = t.upsert(m(rec), strs(on))
 	}
 	return
 }
@@ -0,0 +1,22 @@
+package fsdb_test
+
+import (
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+//	A dropped table's sidecar files must not carry over into a new table of the same name.
+func TestDropTableThenRecreate(t *testing.T) {
+	for _, filePerRecord := range []bool{false, true} {
+		db, dir := openTestDB(t, jsondb.NewDriver(false, fsdb.Options{TableOptions: fsdb.TableOptions{FilePerRecord: filePerRecord}}))
+		execAll(t, db, fsdb.StmtCreateTableIds("T", fsdb.IdUuid4), fsdb.StmtInsertInto("T", fsdb.M{}), fsdb.StmtDropTable("T"), fsdb.StmtCreateTable("T"))
+		if res, err := db.Exec(fsdb.StmtInsertInto("T", fsdb.M{})); err != nil {
+			t.Fatal(err)
+		} else if id, _ := res.LastInsertId(); id != 0 {
+			t.Errorf("filePerRecord=%v: expected a new sequential ID 0, got %d", filePerRecord, id)
+		}
+		closeTestDB(db, dir)
+	}
+}
@@ -21,11 +21,100 @@
 // as `{"createTable": "FooBars"}`. Use the documented `StmtFooBar` methods
 // (ie. `fsdb.StmtCreateTable` and friends) to easily generate statements
 // for use with sql.Exec() and sql.Query(), whether via a `sql.DB` or a `sql.Tx`.
+// Or use the fluent statement builders for all of them, such as
+// `fsdb.Select("Orders").Where(fsdb.M{"Customer": id}).OrderBy("Total", fsdb.Desc).Limit(10).MustBuild()`,
+// which report invalid clauses or combinations of clauses already from their `Build` method.
 //
 // I didn't see the use in parsing real SQL syntax --- each real-world DB has its
 // own syntax quirks, so when moving on from `fsdb` to the real DB, I'd have to adapt
 // most/all SQL statements anyway. This way, it's guaranteed that I'll have to do so.
 //
+// Still, for tooling that can only emit SQL, setting `fsdb.SqlDialect` to `true` has
+// statements starting with a letter (rather than `{`) parsed as this small subset of
+// SQL, compiled to the very same JSON statements:
+//
+//	CREATE TABLE t
+//	DROP TABLE t
+//	INSERT INTO t (col, ...) VALUES (val, ...) [RETURNING * | col, ...]
+//	SELECT * | [DISTINCT] col, ... FROM t [WHERE cond] [ORDER BY col [ASC|DESC], ...] [LIMIT n [OFFSET n]]
+//	SELECT COUNT(*) FROM t [WHERE cond]
+//	UPDATE t SET col = val, ... [WHERE cond] [RETURNING * | col, ...]
+//	DELETE FROM t [WHERE cond] [RETURNING * | col, ...]
+//
+// Keywords are case-insensitive. A `cond` combines `col op val` comparisons (with `op` one of
+// `=`, `!=`, `<>`, `<`, `<=`, `>`, `>=`), `col [NOT] IN (val, ...)`, `col [NOT] IN (SELECT col
+// FROM t [WHERE cond])`, `col [NOT] LIKE val` (or `ILIKE` for case-insensitive matching) and
+// `col IS [NOT] NULL` via `AND`, `OR`, `NOT` and parentheses. Names may be quoted via `"` or
+// `` ` ``, string values via `'` (with `''` escaping `'`). Other values are numbers, `TRUE`,
+// `FALSE`, `NULL` and the placeholders `?` or `$1`, `$2` etc.
+//
+// ## Errors:
+//
+// statements are parsed strictly: exactly one command key, only the clauses known for
+// that command, clause values of the right types and only known `$`-operators. So a
+// typo such as `wher` is rejected rather than silently ignored. Such errors, and
+// references to missing tables, are `*fsdb.StmtError`s wrapping one of the `fsdb.ErrFoo`
+// variables, for use with `errors.Is` and `errors.As`.
+//
+// ## Placeholders:
+//
+// anywhere in a statement's clauses (such as `set` or `where`), `{"$param": 1}` or
+// its short-hand `"$1"` denotes the 1st argument passed to `Exec` or `Query`, and so on.
+// So a statement can be `Prepare`d once and then executed many times with different
+// arguments. The literal string `"$1"` is escaped as `"$$1"` (and `"$$1"` as `"$$$1"`
+// and so on), as the `Stmt*` functions and the statement builders do with all the
+// strings they're given: there, only `M{"$param": 1}` denotes a placeholder.
+//
+// ## Record IDs:
+//
+// every record's `__id` is unique within its table and never reused, even after deletions:
+// by default, a sequence of integers kept in the table's ".meta" sidecar file (next to the
+// table file). Other `fsdb.IdStrategy`s can be chosen per table via `fsdb.StmtCreateTableIds`,
+// and `insertInto` also accepts a caller-supplied `__id` in its `set`.
+//
+// ## Subqueries:
+//
+// wherever `where` criteria take a value or a slice of values (also for `$in` and `$nin`), a nested
+// `selectFrom` statement object with an optional `field` (`__id` by default) can be given instead,
+// such as `{"Customer": {"selectFrom": "Customers", "where": {"LastName": "Collins"}, "field": "__id"}}`.
+// It's replaced by the slice of that field's values in its result rows before the statement runs,
+// within the same statement and (with connection caching) the same connection lock.
+//
+// ## Returning:
+//
+// `insertInto`, `updateWhere` and `deleteFrom` statements with a `returning` clause can also
+// be run via `Query` rather than `Exec`, to get the affected records as rows: as inserted,
+// as updated or as they were before deletion, respectively. `{"returning": true}` returns
+// all their fields (and `__id`), `{"returning": ["__id", "Name"]}` just the specified ones.
+//
+// ## Crash safety:
+//
+// table files are never rewritten in place: each write goes to a temp file next to the
+// table file, is synced to disk, then renamed over the table file. So a crash or a full disk
+// can't leave a table file partially written. A temp file left behind by such an interrupted
+// write doesn't affect any table: opening the database directory reports it (unless modified
+// after the current process started) to `fsdb.OnLeftoverTempFiles` as an `*fsdb.TempFilesError`,
+// whose `Remove` method cleans them up.
+//
+// ## Journaling:
+//
+// by default, every write rewrites the whole table file. With `fsdb.TableOptions.Journal`
+// enabled (for all or some tables, see `fsdb.Options`), inserted, updated and deleted records
+// are instead appended to a ".journal" file next to the table file, one JSON line per record.
+// Loading the table replays its journal on top of the table file, and once the journal grows
+// past `fsdb.TableOptions.JournalCompactSize`, it's folded back into the table file and removed.
+//
+// ## One file per record:
+//
+// with `fsdb.TableOptions.FilePerRecord` enabled, `createTable` creates the table as a directory
+// (named like the table file would be) holding one file per record, named by its `__id` plus the
+// driver's file name extension, such as `Customers.jsondbt/42.jsondbt`. The driver's `Marshal` and
+// `Unmarshal` then handle single records, and writes only touch the files of the affected records:
+// handy for hand-editing and for readable diffs under version control. Existing tables keep the
+// layout they have on disk, regardless of the options. Outside changes to record files are noticed
+// (and then only the changed files re-read) once they change the table directory's modification
+// time, as adding or removing files or saving over them via rename does, but in-place edits are not.
+//
 // ## Connection pooling/caching:
 //
 // works "so-so" with Go's built-in pooling: with
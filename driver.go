@@ -15,20 +15,57 @@ const (
 var (
 	//	Used in `selectWhere` queries, defaults to false. See `M.Match` method for explanation.
 	StrCmp bool
+
+	//	If `true`, statements starting with a letter (rather than `{`) are parsed as a minimal SQL
+	//	dialect (see package docs) instead of as JSON. Defaults to false.
+	SqlDialect bool
+
+	//	If not `nil`, called whenever a connection is opened to a database directory that contains
+	//	temp files left behind by interrupted table writes, such as to log or `Remove` them. Opening
+	//	succeeds regardless, as no table depends on them.
+	OnLeftoverTempFiles func(*TempFilesError)
 )
 
-//	Function that marshals an in-memory data table to a local file.
+//	Function that marshals an in-memory data table (or, for tables stored as one file per record,
+//	a single record) to a local file.
 type Marshal func(v interface{}) ([]byte, error)
 
-//	Function that unmarshals an in-memory data table from a local file.
+//	Function that unmarshals an in-memory data table (or, for tables stored as one file per record,
+//	a single record) from a local file.
 type Unmarshal func(data []byte, v interface{}) error
 
+//	Optional settings for `NewDriver`. The embedded `TableOptions` apply to all tables not listed in `Tables`.
+type Options struct {
+	TableOptions
+
+	//	Per-table settings by table name, used instead of (not merged with) the above defaults.
+	Tables map[string]TableOptions
+}
+
+//	Per-table settings, see `Options`.
+type TableOptions struct {
+	//	If `true`, `createTable` creates the table as a directory holding one file per record, named
+	//	by its `__id` plus the driver's file name extension and (un)marshaled on its own. Writes then
+	//	only touch the files of the affected records. Existing tables keep the layout they have on disk.
+	FilePerRecord bool
+
+	//	If `true`, inserts, updates and deletes are appended to the table's ".journal" sidecar
+	//	file instead of rewriting the whole table file every time. Loading the table replays
+	//	its journal on top of the table file. Not used for tables stored as one file per record.
+	Journal bool
+
+	//	The journal size in bytes past which it's folded back into the table file (and removed).
+	//	If `0`, `DefaultJournalCompactSize` is used.
+	JournalCompactSize int64
+}
+
 //	Implements the `database/sql/driver.Driver` interface.
 type drv struct {
 	marshal   Marshal
 	unmarshal Unmarshal
 	fileExt   string
 	connCache map[string]*conn
+	opts      Options
 }
 
 //	Creates a new `database/sql/driver.Driver` and returns it.
@@ -44,11 +81,16 @@ type drv struct {
 //	this is not sensible for `fsdb`, as each `fsdb.conn` does hold its own complete copies
 //	of all table data files in-memory.
 //	(All table writes are `sync.Mutex`-locking as necessary ONLY if connection-caching is enabled.)
-func NewDriver(fileExt string, connectionCaching bool, marshal Marshal, unmarshal Unmarshal) driver.Driver {
+//
+//	- `opts` -- optional, only the first one is used. See `Options`.
+func NewDriver(fileExt string, connectionCaching bool, marshal Marshal, unmarshal Unmarshal, opts ...Options) driver.Driver {
 	me := &drv{fileExt: fileExt, marshal: marshal, unmarshal: unmarshal}
 	if connectionCaching {
 		me.connCache = map[string]*conn{}
 	}
+	if len(opts) > 0 {
+		me.opts = opts[0]
+	}
 	return me
 }
 
@@ -57,6 +99,13 @@ func (me *drv) ConnectionCaching() bool {
 	return me.connCache != nil
 }
 
+func (me *drv) tableOptions(tableName string) TableOptions {
+	if opts, ok := me.opts.Tables[tableName]; ok {
+		return opts
+	}
+	return me.opts.TableOptions
+}
+
 //	Implements the `database/sql/driver.Driver.Open` interface method.
 func (me *drv) Open(dirPath string) (_ driver.Conn, err error) {
 	var conn *conn
@@ -65,7 +114,7 @@ func (me *drv) Open(dirPath string) (_ driver.Conn, err error) {
 	}
 	if conn == nil {
 		conn, err = newConn(me, dirPath)
-		if me.connCache != nil {
+		if me.connCache != nil && err == nil {
 			me.connCache[dirPath] = conn
 		}
 	}
@@ -74,35 +123,75 @@ func (me *drv) Open(dirPath string) (_ driver.Conn, err error) {
 
 //	A convenience short-hand. Used for actual records, as well as `where` criteria (in
 //	selectFrom, deleteFrom, updateWhere) and `set` data (in insertInto and updateWhere).
+//
+//	Field names in `where` criteria, `set` data (other than for insertInto) and column
+//	lists (such as `fields` or `orderBy`) may also be dot-separated paths into nested
+//	objects and slices, such as `Address.City` or `Items.0.Sku`: unless a top-level field
+//	of exactly that name exists. When `set`ting such a path, missing objects are created, and slice
+//	indexes may address existing elements or the one just past the end (appending). Setting a path
+//	through any other value (or through a slice by a non-index) fails with `ErrInvalidPath`.
 type M map[string]interface{}
 
 //	If `me` is a record, returns whether it matches the specified criteria.
 //
 //	- recID: the `__id` of `me`, if any (since this isn't stored in the record itself)
 //
-//	- filters: one or more criteria, `AND`-ed together. Each criteria is a slice of possible values, `OR`-ed together
+//	- filters: one or more criteria, `AND`-ed together. Each criteria is either a slice of possible values, `OR`-ed together,
+//	or an operator object such as `{"$gt": 100}` or `{"$gte": 1, "$lt": 10}` (multiple operators are `AND`-ed together).
+//	Supported operators: `$eq`, `$ne`, `$gt`, `$gte`, `$lt`, `$lte`, `$in`, `$nin` (the latter two take a slice of values).
+//	For string values, `$regex` (Go RE2 syntax), `$like` (SQL-like: `%` for any text, `_` for any single character),
+//	`$prefix` and `$contains` also match case-insensitively given `"$options": "i"`, such as `{"$like": "a%", "$options": "i"}`.
+//	Numbers compare numerically regardless of their Go type, strings lexically and times (`time.Time` or RFC 3339 strings) chronologically.
+//	Criteria can also be grouped at any depth via the special keys `$and` and `$or` (each taking a slice of nested
+//	filters) and `$not` (taking one nested filter, or a slice of them that must not *all* match), for example
+//	`{"$or": [{"FirstName": "Alice"}, {"City": "Berlin"}]}`.
 //
 //	- strCmp: if `false`, just compares `interface{}==interface{}`. If `true`, also compares `fmt.Sprintf("%v", interface{}) == fmt.Sprintf("%v", interface{})`
 func (me M) Match(recId string, filters M, strCmp bool) (isMatch bool) {
-	matchAny := func(fn string, rvx interface{}, fvx []interface{}) bool {
-		for _, fv := range fvx {
-			if rvx == fv || strCmp && strf("%v", rvx) == strf("%v", fv) {
-				return true
-			}
-		}
-		return false
-	}
 	for fn, fx := range filters {
-		if fn != IdField || len(recId) > 0 {
-			if !matchAny(fn, umisc.IfX(fn == IdField, recId, me[fn]), interfaces(fx)) {
+		switch fn {
+		case opAnd, opOr, opNot:
+			if !me.matchGroup(recId, fn, fx, strCmp) {
 				return
 			}
+		default:
+			if fn != IdField || len(recId) > 0 {
+				if !matchValue(umisc.IfX(fn == IdField, recId, me.at(fn)), fx, strCmp) {
+					return
+				}
+			}
 		}
 	}
 	isMatch = true
 	return
 }
 
+func (me M) matchGroup(recId, op string, fx interface{}, strCmp bool) bool {
+	subs := interfaces(fx)
+	switch op {
+	case opOr:
+		for _, sub := range subs {
+			if me.Match(recId, m(sub), strCmp) {
+				return true
+			}
+		}
+		return false
+	case opNot:
+		for _, sub := range subs {
+			if !me.Match(recId, m(sub), strCmp) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, sub := range subs {
+		if !me.Match(recId, m(sub), strCmp) {
+			return false
+		}
+	}
+	return true
+}
+
 func interfaces(ix interface{}) (slice []interface{}) {
 	var ok bool
 	if slice, ok = ix.([]interface{}); (!ok) && ix != nil {
@@ -111,9 +200,24 @@ func interfaces(ix interface{}) (slice []interface{}) {
 	return
 }
 
+func integer(ix interface{}) int {
+	f, _ := number(ix)
+	return int(f)
+}
+
+func strs(ix interface{}) (slice []string) {
+	if sl, ok := ix.([]string); ok {
+		return sl
+	}
+	for _, v := range interfaces(ix) {
+		slice = append(slice, strf("%v", v))
+	}
+	return
+}
+
 func m(ix interface{}) (m M) {
 	if m, _ = ix.(M); m == nil {
-		if mm := ix.(map[string]interface{}); mm != nil {
+		if mm, _ := ix.(map[string]interface{}); mm != nil {
 			m = M(mm)
 		}
 	}
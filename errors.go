@@ -0,0 +1,93 @@
+package fsdb
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+var (
+	//	A statement is neither valid JSON nor (if enabled) valid `SqlDialect`.
+	ErrSyntax = errors.New("syntax error")
+
+	//	A statement has no known command key such as `selectFrom`, or more than one.
+	ErrUnknownCommand = errors.New("unknown command")
+
+	//	A statement has a key that's not a clause of its command, such as `wher`.
+	ErrUnknownClause = errors.New("unknown clause")
+
+	//	A clause value is of the wrong type, such as a `where` that's not an object.
+	ErrInvalidClause = errors.New("invalid clause")
+
+	//	A `where` criteria or `set` has a `$`-prefixed key that's not a known operator.
+	ErrUnknownOperator = errors.New("unknown operator")
+
+	//	A statement refers to a table that doesn't exist.
+	ErrNoSuchTable = errors.New("no such table")
+
+	//	A field path to be set indexes a slice past its end or leads through a non-object, see `M`.
+	ErrInvalidPath = errors.New("invalid path")
+
+	//	The database directory contains temp files left behind by interrupted table writes, see `TempFilesError`.
+	ErrLeftoverTempFiles = errors.New("leftover temp files")
+)
+
+//	Describes why a statement was rejected. Its `Err` is one of the `ErrFoo` variables,
+//	so that `errors.Is(err, fsdb.ErrUnknownClause)` works as well as `errors.As`.
+type StmtError struct {
+	Err error
+
+	//	The offending command, clause or operator key, if any.
+	Key string
+
+	//	Further details, if any.
+	Detail string
+}
+
+func stmtErr(err error, key string, detailFormat string, detailArgs ...interface{}) *StmtError {
+	return &StmtError{Err: err, Key: key, Detail: strf(detailFormat, detailArgs...)}
+}
+
+func (me *StmtError) Error() (msg string) {
+	msg = "fsdb: " + me.Err.Error()
+	if me.Key != "" {
+		msg += strf(" '%s'", me.Key)
+	}
+	if me.Detail != "" {
+		msg += ": " + me.Detail
+	}
+	return
+}
+
+func (me *StmtError) Unwrap() error {
+	return me.Err
+}
+
+//	Passed to `OnLeftoverTempFiles` when opening a database directory that contains temp files
+//	left behind by interrupted table writes (such as from a crash or a full disk). The table files
+//	themselves are intact, since they're only ever replaced by renaming a completely written and
+//	synced temp file over them, so the `Paths` can be inspected or just `Remove`d. Temp files
+//	modified after the current process started are not reported, as they may belong to writes
+//	still in progress.
+type TempFilesError struct {
+	Paths []string
+}
+
+func (me *TempFilesError) Error() string {
+	return strf("fsdb: %s: %s", ErrLeftoverTempFiles.Error(), strings.Join(me.Paths, ", "))
+}
+
+//	Removes all `Paths` (those already gone are ignored).
+func (me *TempFilesError) Remove() (err error) {
+	for _, filePath := range me.Paths {
+		if e := os.Remove(filePath); e != nil && !os.IsNotExist(e) && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+//	Returns `ErrLeftoverTempFiles`, for use with `errors.Is`.
+func (me *TempFilesError) Unwrap() error {
+	return ErrLeftoverTempFiles
+}
@@ -7,8 +7,8 @@
 // In this newly created (or overwritten) database:
 // - via `createTable`, creates 3 'tables'/collections: Customers, Products, Orders
 // - via `insertInto`, populates those with semi-random records
-// - via `selectFrom`, queries the DB to find all Customers with *LastName=Collins*
-// - via `deleteFrom`, deletes all Orders belonging to those customers
+// - via `count`, queries the DB for the number of Customers with *LastName=Collins*
+// - via `deleteFrom` with a `selectFrom` subquery, deletes all Orders belonging to those customers
 // - via `updateWhere`, for all *FirstName=Alice&City=Berlin* Customers, sets their City to Seattle
 package main
 
@@ -112,13 +112,14 @@ func addOrders(tx *sql.Tx) (err error) {
 	return
 }
 
-func conn(dbDrvMode, dbDirPath string) (db *sql.DB, err error) {
+func conn(dbDrvMode, dbDirPath string, journal, recordFiles bool) (db *sql.DB, err error) {
+	opts := fsdb.Options{TableOptions: fsdb.TableOptions{Journal: journal, FilePerRecord: recordFiles}}
 	switch dbDrvMode {
 	case "json":
-		sql.Register(fsdb_json.DriverName, fsdb_json.NewDriver(false))
+		sql.Register(fsdb_json.DriverName, fsdb_json.NewDriver(false, opts))
 		db, err = sql.Open(fsdb_json.DriverName, dbDirPath)
 	case "toml":
-		sql.Register(fsdb_toml.DriverName, fsdb_toml.NewDriver(false))
+		sql.Register(fsdb_toml.DriverName, fsdb_toml.NewDriver(false, opts))
 		db, err = sql.Open(fsdb_toml.DriverName, dbDirPath)
 	default:
 		err = fmt.Errorf("Unknown -drv flag value %#v: must be one of: %v", dbDrvMode, dbDrvModes)
@@ -130,10 +131,12 @@ func main() {
 	defaultDir := udevgo.GopathSrcGithub("metaleap", "go-fsdb", "go-fsdb-test", "testdbs", time.Now().Format("2006-01-02_15-04-05"))
 	dbDirPath := flag.String("dbdir", defaultDir, "Specify the path to a DB directory. I will open or create a JSON-DB in there.")
 	dbDrvMode := flag.String("drv", dbDrvModes[0], fmt.Sprintf("Must be one of: %v.", dbDrvModes))
+	journal := flag.Bool("journal", false, "Append table writes to per-table journal files instead of rewriting whole table files.")
+	recordFiles := flag.Bool("recordfiles", false, "Create tables as directories holding one file per record.")
 	flag.Parse()
 	ufs.EnsureDirExists(*dbDirPath)
 
-	db, err := conn(*dbDrvMode, *dbDirPath)
+	db, err := conn(*dbDrvMode, *dbDirPath, *journal, *recordFiles)
 	if err == nil { // panic once at the end instead of everywhere
 		log.Printf("JSON-DB location: %s", *dbDirPath)
 		defer db.Close()
@@ -156,36 +159,18 @@ func main() {
 				log.Printf("Rollback error: %v", err2)
 			}
 		}
-		var rows *sql.Rows
 		queryName := "Collins"
-		var recIds []string
-		if rows, err = db.Query(fsdb.StmtSelectFrom("Customers", fsdb.M{"LastName": queryName})); err == nil {
-			defer rows.Close()
-			var cursor udb.SqlCursor
-			if err = cursor.PrepareColumns(rows); err == nil {
-				var rec map[string]interface{}
-				for rows.Next() {
-					if rec, err = cursor.Scan(rows); err == nil {
-						// log.Printf("Record found for LastName=%#v:\t%v\n", queryName, rec)
-						recIds = append(recIds, rec[fsdb.IdField].(string))
-					} else {
-						break
-					}
-				}
-			}
-			if err == nil {
-				err = rows.Err()
-			}
-			if err == nil {
-				log.Printf("Found %v 'Customers' with LastName=%#v---deleting all their 'Orders':", len(recIds), queryName)
-				var numRows int64
-				if numRows, err = udb.Exec(db, false, fsdb.StmtDeleteFrom("Orders", fsdb.M{"Customer": recIds})); err == nil {
-					log.Printf("..deletion affected %v rows", numRows)
-					queryName = "Alice"
-					log.Printf("Updating all FirstName=%#v 'Customers' from Berlin to Seattle:", queryName)
-					if numRows, err = udb.Exec(db, false, fsdb.StmtUpdateWhere("Customers", fsdb.M{"City": "Seattle"}, fsdb.M{"City": "Berlin", "FirstName": "Alice"})); err == nil {
-						log.Printf("..update affected %v records.", numRows)
-					}
+		var numFound int64
+		if err = db.QueryRow(fsdb.StmtCount("Customers", fsdb.M{"LastName": queryName})).Scan(&numFound); err == nil {
+			log.Printf("Found %v 'Customers' with LastName=%#v---deleting all their 'Orders':", numFound, queryName)
+			var numRows int64
+			customers := fsdb.Select("Customers").Where(fsdb.M{"LastName": queryName}).Subquery(fsdb.IdField)
+			if numRows, err = udb.Exec(db, false, fsdb.StmtDeleteFrom("Orders", fsdb.M{"Customer": customers})); err == nil {
+				log.Printf("..deletion affected %v rows", numRows)
+				queryName = "Alice"
+				log.Printf("Updating all FirstName=%#v 'Customers' from Berlin to Seattle:", queryName)
+				if numRows, err = udb.Exec(db, false, fsdb.StmtUpdateWhere("Customers", fsdb.M{"City": "Seattle"}, fsdb.M{"City": "Berlin", "FirstName": "Alice"})); err == nil {
+					log.Printf("..update affected %v records.", numRows)
 				}
 			}
 		}
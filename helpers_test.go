@@ -0,0 +1,91 @@
+package fsdb_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+var numTestDrivers int
+
+//	One result row, by column name.
+type row = map[string]interface{}
+
+//	Registers `drv` under a new name and opens a database with it in a new temporary directory,
+//	to be cleaned up via `closeTestDB`.
+func openTestDB(t *testing.T, drv driver.Driver) (db *sql.DB, dir string) {
+	var err error
+	if dir, err = ioutil.TempDir("", "fsdb"); err != nil {
+		t.Fatal(err)
+	}
+	return reopenTestDB(t, drv, dir), dir
+}
+
+//	Like `openTestDB`, but for the existing database directory `dir`.
+func reopenTestDB(t *testing.T, drv driver.Driver, dir string) (db *sql.DB) {
+	numTestDrivers++
+	drvName := jsondb.DriverName + "/test" + strconv.Itoa(numTestDrivers)
+	sql.Register(drvName, drv)
+	db, err := sql.Open(drvName, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func closeTestDB(db *sql.DB, dir string) {
+	db.Close()
+	os.RemoveAll(dir)
+}
+
+func execAll(t *testing.T, db *sql.DB, stmts ...string) {
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+//	Returns all rows of `rows` (which it closes), each as a map of column names to values.
+func scanAll(t *testing.T, rows *sql.Rows) (all []row) {
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		vals, ptrs := make([]interface{}, len(cols)), make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err = rows.Scan(ptrs...); err != nil {
+			t.Fatal(err)
+		}
+		rec := row{}
+		for i, col := range cols {
+			if b, isBytes := vals[i].([]byte); isBytes {
+				vals[i] = string(b)
+			}
+			rec[col] = vals[i]
+		}
+		all = append(all, rec)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func expectRows(t *testing.T, stmt string, got, want []row) {
+	if len(got) != 0 || len(want) != 0 {
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", stmt, want, got)
+		}
+	}
+}
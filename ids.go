@@ -0,0 +1,148 @@
+package fsdb
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//	How new record `__id`s are generated by `insertInto` (and `upsertInto`), see `StmtCreateTableIds`.
+type IdStrategy string
+
+const (
+	//	Monotonic integers, persisted per table so that they're never reused. The default.
+	IdSeq IdStrategy = "seq"
+	//	Random UUIDs (version 4) such as `0b7c4e7e-3d3a-4f6e-9a59-2f1d1e3c5a7b`.
+	IdUuid4 IdStrategy = "uuid4"
+	//	Time-ordered UUIDs (version 7), which sort by creation time.
+	IdUuid7 IdStrategy = "uuid7"
+	//	ULIDs such as `01HZX3V8J5Q8K9R7C6M2N4P0TW`, which sort by creation time.
+	IdUlid IdStrategy = "ulid"
+)
+
+var idStrategies = []string{string(IdSeq), string(IdUuid4), string(IdUuid7), string(IdUlid)}
+
+//	Per-table settings and state not stored in the table file itself, but in its ".meta" sidecar file.
+type tableMeta struct {
+	IdStrategy IdStrategy `json:"idStrategy"`
+	NextId     int64      `json:"nextId"`
+
+	dirty bool
+}
+
+//	Compares the record `__id`s `a` and `b` like `strings.Compare` does, except that numeric
+//	ones compare numerically and before all non-numeric ones. This is the order of `selectFrom`
+//	results without `orderBy`, and the order in which the built-in codecs write records.
+func CompareIds(a, b string) int {
+	ia, aerr := strconv.ParseInt(a, 10, 64)
+	ib, berr := strconv.ParseInt(b, 10, 64)
+	if aerr == nil && berr == nil {
+		return cmpOrd(ia < ib, ia > ib)
+	} else if (aerr == nil) != (berr == nil) {
+		return cmpOrd(aerr == nil, berr == nil)
+	}
+	return strings.Compare(a, b)
+}
+
+//	Returns the keys of `m` in `CompareIds` order: for `Marshal` implementations, so that
+//	unchanged records (and fields) are always written in the same order.
+func SortedKeys(m M) (keys []string) {
+	keys = make([]string, 0, len(m))
+	for k, _ := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return CompareIds(keys[i], keys[j]) < 0 })
+	return
+}
+
+func metaFilePath(tableFilePath string) string {
+	return tableFilePath + ".meta"
+}
+
+//	Loads `me.meta` from its sidecar file (if any) and ensures that its `NextId`
+//	exceeds every numeric `__id` in `me.recs` and never decreases.
+func (me *table) loadMeta() (err error) {
+	var raw []byte
+	meta := tableMeta{IdStrategy: IdSeq}
+	if raw, err = ioutil.ReadFile(metaFilePath(me.filePath)); err == nil {
+		err = json.Unmarshal(raw, &meta)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if err == nil {
+		if meta.NextId < me.meta.NextId {
+			meta.NextId = me.meta.NextId
+		}
+		for rid, _ := range me.recs {
+			if id, e := strconv.ParseInt(rid, 10, 64); e == nil && id >= meta.NextId {
+				meta.NextId, meta.dirty = id+1, true
+			}
+		}
+		me.meta = meta
+	}
+	return
+}
+
+func (me *table) persistMeta() (err error) {
+	var raw []byte
+	if raw, err = json.Marshal(&me.meta); err == nil {
+		if err = writeFileAtomic(metaFilePath(me.filePath), raw); err == nil {
+			me.meta.dirty = false
+		}
+	}
+	return
+}
+
+//	Generates a new `__id` as per `me.meta.IdStrategy`. Callers lock `me` as necessary.
+func (me *table) newId() (sid string, err error) {
+	switch me.meta.IdStrategy {
+	case IdUuid4, IdUuid7:
+		var b [16]byte
+		if _, err = rand.Read(b[:]); err == nil {
+			if me.meta.IdStrategy == IdUuid4 {
+				b[6] = 0x40 | (b[6] & 0x0f)
+			} else {
+				ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+				var ts [8]byte
+				binary.BigEndian.PutUint64(ts[:], ms)
+				copy(b[:6], ts[2:])
+				b[6] = 0x70 | (b[6] & 0x0f)
+			}
+			b[8] = 0x80 | (b[8] & 0x3f)
+			h := hex.EncodeToString(b[:])
+			sid = h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+		}
+	case IdUlid:
+		var b [16]byte
+		if _, err = rand.Read(b[6:]); err == nil {
+			ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+			var ts [8]byte
+			binary.BigEndian.PutUint64(ts[:], ms)
+			copy(b[:6], ts[2:])
+			sid = ulidString(b)
+		}
+	default:
+		sid = strconv.FormatInt(me.meta.NextId, 10)
+	}
+	return
+}
+
+//	Crockford's base32 of the 128 bits in `b`, as 26 characters (the first one only encoding 3 bits).
+func ulidString(b [16]byte) string {
+	const digits = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	hi, lo := binary.BigEndian.Uint64(b[:8]), binary.BigEndian.Uint64(b[8:])
+	var s [26]byte
+	for i := len(s) - 1; i >= 0; i-- {
+		s[i] = digits[lo&31]
+		lo = (lo >> 5) | (hi << 59)
+		hi >>= 5
+	}
+	return string(s[:])
+}
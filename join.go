@@ -0,0 +1,91 @@
+package fsdb
+
+import (
+	"sort"
+)
+
+type join struct {
+	table, local, foreign, as string
+	left                      bool
+}
+
+func newJoins(joins interface{}) (all []join, err error) {
+	for _, jx := range interfaces(joins) {
+		jm := m(jx)
+		j := join{table: strf("%v", jm["table"]), local: strf("%v", jm["local"]), foreign: IdField, left: jm["left"] == true}
+		if jm["foreign"] != nil {
+			j.foreign = strf("%v", jm["foreign"])
+		}
+		if j.as = j.table; jm["as"] != nil {
+			j.as = strf("%v", jm["as"])
+		}
+		if jm == nil || jm["table"] == nil || jm["local"] == nil {
+			err = errf("Invalid join %v: needs at least `table` and `local`", jx)
+			break
+		}
+		all = append(all, j)
+	}
+	return
+}
+
+//	Replaces each record in `me` with one merged record per matching record in
+//	the `foreign` table, whose fields (including its `__id`) are prefixed with
+//	`as` and `.`, such as `Customer.City`. If the `local` field holds a slice,
+//	each of its values is looked up. Records without any match are dropped, or
+//	for a `left` join kept as-is.
+func (me *rows) join(conn *conn, j join) (err error) {
+	var ft *table
+	if ft, err = conn.tables.get(j.table); err == nil {
+		var index map[string][]string
+		if j.foreign != IdField {
+			index = map[string][]string{}
+			frids := make([]string, 0, len(ft.recs))
+			for frid, _ := range ft.recs {
+				frids = append(frids, frid)
+			}
+			sort.Slice(frids, func(i, k int) bool { return CompareIds(frids[i], frids[k]) < 0 })
+			for _, frid := range frids {
+				for _, fv := range interfaces(m(ft.recs[frid]).at(j.foreign)) {
+					key := strf("%v", fv)
+					index[key] = append(index[key], frid)
+				}
+			}
+		}
+		rids, recs := me.rids, me.recs
+		me.rids, me.recs = make([]string, 0, len(rids)), make([]M, 0, len(recs))
+		for i, rec := range recs {
+			var lvals []interface{}
+			if j.local == IdField {
+				lvals = []interface{}{rids[i]}
+			} else {
+				lvals = interfaces(rec.at(j.local))
+			}
+			matched := false
+			for _, lv := range lvals {
+				key := strf("%v", lv)
+				frids := index[key]
+				if index == nil {
+					if _, ok := ft.recs[key]; ok {
+						frids = []string{key}
+					}
+				}
+				for _, frid := range frids {
+					if frec := m(ft.recs[frid]); frec != nil {
+						merged := M{j.as + "." + IdField: frid}
+						for fn, fv := range rec {
+							merged[fn] = fv
+						}
+						for fn, fv := range frec {
+							merged[j.as+"."+fn] = fv
+						}
+						me.rids, me.recs, matched = append(me.rids, rids[i]), append(me.recs, merged), true
+					}
+				}
+			}
+			if j.left && !matched {
+				me.rids, me.recs = append(me.rids, rids[i]), append(me.recs, rec)
+			}
+		}
+	}
+	return
+}
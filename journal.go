@@ -0,0 +1,154 @@
+package fsdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+//	Journal size in bytes past which it's compacted, unless `TableOptions.JournalCompactSize` specifies otherwise.
+const DefaultJournalCompactSize = 1 << 20
+
+//	One line in a ".journal" sidecar file: the record as of its latest write, or `nil` if it was deleted.
+type journalEntry struct {
+	Id  string `json:"id"`
+	Rec M      `json:"rec"`
+}
+
+func journalFilePath(tableFilePath string) string {
+	return tableFilePath + ".journal"
+}
+
+//	The sidecar files that accompany a table file and are renamed or removed along with it.
+func sidecarFilePaths(tableFilePath string) []string {
+	return []string{metaFilePath(tableFilePath), journalFilePath(tableFilePath)}
+}
+
+//	Records that the record `rid` was inserted, updated or deleted since the last `persist`.
+//	Callers lock `me` as necessary.
+func (me *table) touch(rid string) {
+	if me.touched == nil {
+		me.touched = map[string]bool{}
+	}
+	me.touched[rid] = true
+}
+
+//	Appends an entry for every `me.touched` record to the journal, then compacts it if it
+//	has grown past `opts.JournalCompactSize`. A crash mid-append leaves at most a partial last
+//	line behind, which `replayJournal` ignores as that write never completed, and which is cut
+//	off here before appending. Callers lock `me` as necessary.
+func (me *table) appendJournal(opts TableOptions) (err error) {
+	var (
+		buf  bytes.Buffer
+		line []byte
+		f    *os.File
+		size int64
+	)
+	if len(me.touched) == 0 {
+		return
+	}
+	rids := make([]string, 0, len(me.touched))
+	for rid, _ := range me.touched {
+		rids = append(rids, rid)
+	}
+	sort.Slice(rids, func(i, j int) bool { return CompareIds(rids[i], rids[j]) < 0 })
+	for _, rid := range rids {
+		if line, err = json.Marshal(&journalEntry{Id: rid, Rec: m(me.recs[rid])}); err != nil {
+			return
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if f, err = os.OpenFile(journalFilePath(me.filePath), os.O_RDWR|os.O_CREATE, 0644); err == nil {
+		if size, err = cutPartialLine(f); err == nil {
+			if _, err = f.WriteAt(buf.Bytes(), size); err == nil {
+				size += int64(buf.Len())
+				err = f.Sync()
+			}
+		}
+		if e := f.Close(); err == nil {
+			err = e
+		}
+	}
+	if err == nil {
+		me.touched, me.lastLoad = nil, time.Now()
+		compactSize := opts.JournalCompactSize
+		if compactSize <= 0 {
+			compactSize = DefaultJournalCompactSize
+		}
+		if size > compactSize {
+			err = me.compact()
+		}
+	}
+	return
+}
+
+//	Truncates the journal `f` after its last complete line, if its last line is partial.
+//	Returns the size of `f` afterwards.
+func cutPartialLine(f *os.File) (size int64, err error) {
+	var (
+		fi  os.FileInfo
+		raw []byte
+	)
+	if fi, err = f.Stat(); err == nil && fi.Size() > 0 {
+		raw, size = make([]byte, fi.Size()), fi.Size()
+		if _, err = f.ReadAt(raw[size-1:], size-1); err == nil && raw[size-1] != '\n' {
+			if _, err = f.ReadAt(raw, 0); err == nil {
+				size = int64(bytes.LastIndexByte(raw, '\n') + 1)
+				err = f.Truncate(size)
+			}
+		}
+	}
+	return
+}
+
+//	Writes all of `me.recs` to the table file, then removes the journal (if any) as it's now fully contained in there.
+func (me *table) compact() (err error) {
+	var raw []byte
+	if raw, err = me.conn.drv.marshal(me.recs); err == nil {
+		if err = writeFileAtomic(me.filePath, raw); err == nil {
+			me.touched, me.lastLoad = nil, time.Now()
+			if err = os.Remove(journalFilePath(me.filePath)); os.IsNotExist(err) {
+				err = nil
+			}
+		}
+	}
+	return
+}
+
+//	Applies all entries in the journal of the table file at `tableFilePath` (if any) to `recs`, in order.
+//	A partial last line (from an append interrupted by a crash) is ignored, see `appendJournal`.
+func replayJournal(tableFilePath string, recs M) (err error) {
+	var raw []byte
+	filePath := journalFilePath(tableFilePath)
+	if raw, err = ioutil.ReadFile(filePath); os.IsNotExist(err) {
+		err = nil
+	} else if err == nil {
+		lines := bytes.Split(raw, []byte{'\n'})
+		for i, line := range lines[:len(lines)-1] {
+			if len(bytes.TrimSpace(line)) > 0 {
+				var entry journalEntry
+				if e := json.Unmarshal(line, &entry); e != nil {
+					err = errf("Corrupt journal '%s' at line %d: %v", filePath, i+1, e)
+					break
+				} else if entry.Rec == nil {
+					delete(recs, entry.Id)
+				} else {
+					recs[entry.Id] = entry.Rec
+				}
+			}
+		}
+	}
+	return
+}
+
+//	The modification time of the journal of `me`, or the zero `time.Time` if there is none.
+func (me *table) journalModTime() (modTime time.Time) {
+	if fi, err := os.Stat(journalFilePath(me.filePath)); err == nil {
+		modTime = fi.ModTime()
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package fsdb_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestJournal(t *testing.T) {
+	journaled := fsdb.Options{TableOptions: fsdb.TableOptions{Journal: true}}
+	db, dir := openTestDB(t, jsondb.NewDriver(false, journaled))
+	defer closeTestDB(db, dir)
+	tableFile := filepath.Join(dir, "T"+jsondb.FileExt)
+	journalFile := tableFile + ".journal"
+	readFile := func(filePath string) []byte {
+		raw, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+	execAll(t, db, fsdb.StmtCreateTable("T"))
+	created := readFile(tableFile)
+	execAll(t, db, fsdb.StmtInsertInto("T", fsdb.M{"Name": "a"}), fsdb.StmtInsertInto("T", fsdb.M{"Name": "b"}),
+		fsdb.StmtInsertInto("T", fsdb.M{"Name": "c"}), fsdb.StmtUpdateWhere("T", fsdb.M{"Name": "B"}, fsdb.M{"Name": "b"}),
+		fsdb.StmtDeleteFrom("T", fsdb.M{"Name": "c"}))
+	if !bytes.Equal(readFile(tableFile), created) {
+		t.Error("expected the table file to stay as-is")
+	} else if lines := bytes.Count(readFile(journalFile), []byte{'\n'}); lines != 5 {
+		t.Errorf("expected 5 journal lines, got %d", lines)
+	}
+
+	for i, test := range []struct {
+		tornTail string
+		insert   string
+		opts     fsdb.Options
+		want     []row
+	}{
+		{want: []row{{"Name": "a"}, {"Name": "B"}}},
+		{tornTail: `{"id":"9","rec":{"Na`, want: []row{{"Name": "a"}, {"Name": "B"}}},
+		{tornTail: `{"id":"9","rec":{"Na`, insert: "d", want: []row{{"Name": "a"}, {"Name": "B"}, {"Name": "d"}}},
+		{insert: "e", opts: fsdb.Options{TableOptions: fsdb.TableOptions{Journal: true, JournalCompactSize: 1}},
+			want: []row{{"Name": "a"}, {"Name": "B"}, {"Name": "d"}, {"Name": "e"}}},
+		{want: []row{{"Name": "a"}, {"Name": "B"}, {"Name": "d"}, {"Name": "e"}}},
+	} {
+		var journal []byte
+		if test.tornTail != "" {
+			journal = append(readFile(journalFile), test.tornTail...)
+			if err := ioutil.WriteFile(journalFile, journal, 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if test.opts.Journal {
+			journaled = test.opts
+		}
+		db2 := reopenTestDB(t, jsondb.NewDriver(false, journaled), dir)
+		if test.insert != "" {
+			execAll(t, db2, fsdb.StmtInsertInto("T", fsdb.M{"Name": test.insert}))
+		}
+		rows, err := db2.Query(fsdb.StmtSelectFromFields("T", nil, []string{"Name"}, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectRows(t, fmt.Sprint(i), scanAll(t, rows), test.want)
+		db2.Close()
+
+		raw, err := ioutil.ReadFile(journalFile)
+		switch {
+		case test.opts.Journal:
+			if !os.IsNotExist(err) {
+				t.Errorf("%d: expected the journal to be compacted, got %v", i, err)
+			}
+		case test.insert != "":
+			if !bytes.HasSuffix(raw, []byte{'\n'}) || bytes.Contains(raw, []byte(test.tornTail)) {
+				t.Errorf("%d: expected the partial last line to be cut off before appending, got %s", i, raw)
+			}
+		case test.tornTail != "":
+			if !bytes.Equal(raw, journal) {
+				t.Errorf("%d: expected reading to leave the journal as-is, got %s", i, raw)
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@
 package jsondb
 
 import (
+	"bytes"
 	"database/sql/driver"
 	"encoding/json"
 
@@ -19,9 +20,49 @@ var (
 	FileExt = ".jsondbt"
 )
 
-//	Returns a `fsdb.NewDriver` initialized with `FileExt` and JSON un/marshalers.
-func NewDriver(connectionCaching bool) driver.Driver {
+//	Returns a `fsdb.NewDriver` initialized with `FileExt` and JSON un/marshalers, and `opts` (if any).
+func NewDriver(connectionCaching bool, opts ...fsdb.Options) driver.Driver {
 	jsonUnmarshal := json.Unmarshal
-	jsonMarshal := func(v interface{}) ([]byte, error) { return json.MarshalIndent(v, "", " ") }
-	return fsdb.NewDriver(FileExt, connectionCaching, jsonMarshal, jsonUnmarshal)
+	return fsdb.NewDriver(FileExt, connectionCaching, jsonMarshal, jsonUnmarshal, opts...)
+}
+
+//	Like `json.MarshalIndent`, but writes the top-level keys (the `__id`s of a data table,
+//	or the fields of a record) in `fsdb.SortedKeys` order. Nested objects' keys are sorted by
+//	`encoding/json` itself, so the output for unchanged records never changes.
+func jsonMarshal(v interface{}) (data []byte, err error) {
+	var (
+		m        map[string]interface{}
+		buf      bytes.Buffer
+		key, val []byte
+	)
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m = t
+	case fsdb.M:
+		m = t
+	default:
+		return json.MarshalIndent(v, "", " ")
+	}
+	buf.WriteString("{")
+	for i, k := range fsdb.SortedKeys(m) {
+		if key, err = json.Marshal(k); err == nil {
+			val, err = json.MarshalIndent(m[k], " ", " ")
+		}
+		if err != nil {
+			return
+		}
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n ")
+		buf.Write(key)
+		buf.WriteString(": ")
+		buf.Write(val)
+	}
+	if len(m) > 0 {
+		buf.WriteString("\n")
+	}
+	buf.WriteString("}")
+	data = buf.Bytes()
+	return
 }
@@ -0,0 +1,193 @@
+package fsdb
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const (
+	opEq  = "$eq"
+	opNe  = "$ne"
+	opGt  = "$gt"
+	opGte = "$gte"
+	opLt  = "$lt"
+	opLte = "$lte"
+	opIn  = "$in"
+	opNin = "$nin"
+
+	opRegex    = "$regex"
+	opLike     = "$like"
+	opPrefix   = "$prefix"
+	opContains = "$contains"
+	opOptions  = "$options"
+
+	opAnd = "$and"
+	opOr  = "$or"
+	opNot = "$not"
+)
+
+//	Returns `fx` as an operator object (such as `{"$gt": 100}`) if all its keys start with `$`, else `nil`.
+func ops(fx interface{}) (ops M) {
+	if ops = m(fx); len(ops) > 0 {
+		for k, _ := range ops {
+			if !strings.HasPrefix(k, "$") {
+				return nil
+			}
+		}
+	} else {
+		ops = nil
+	}
+	return
+}
+
+//	Returns whether the record field value `rv` satisfies the criteria `fx`: either
+//	an operator object, or one or more possible values, `OR`-ed together.
+func matchValue(rv, fx interface{}, strCmp bool) bool {
+	if fops := ops(fx); fops != nil {
+		for op, ov := range fops {
+			if isPatternOp(op) {
+				if !matchPattern(rv, op, ov, fops[opOptions], strCmp) {
+					return false
+				}
+			} else if op != opOptions && !matchOp(rv, op, ov, strCmp) {
+				return false
+			}
+		}
+		return true
+	}
+	return matchAny(rv, interfaces(fx), strCmp)
+}
+
+func matchAny(rv interface{}, fvx []interface{}, strCmp bool) bool {
+	for _, fv := range fvx {
+		if equal(rv, fv, strCmp) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchOp(rv interface{}, op string, ov interface{}, strCmp bool) bool {
+	switch op {
+	case opEq:
+		return equal(rv, ov, strCmp)
+	case opNe:
+		return !equal(rv, ov, strCmp)
+	case opIn:
+		return matchAny(rv, interfaces(ov), strCmp)
+	case opNin:
+		return !matchAny(rv, interfaces(ov), strCmp)
+	case opGt, opGte, opLt, opLte:
+		if c, ok := compare(rv, ov); ok {
+			switch op {
+			case opGt:
+				return c > 0
+			case opGte:
+				return c >= 0
+			case opLt:
+				return c < 0
+			case opLte:
+				return c <= 0
+			}
+		}
+	}
+	return false
+}
+
+//	Numbers compare numerically regardless of their Go type, times chronologically
+//	(also against strings in RFC 3339 format), strings lexically, everything else
+//	via `reflect.DeepEqual` and, if `strCmp`, their `fmt.Sprintf("%v")` representations.
+func equal(a, b interface{}, strCmp bool) bool {
+	if c, ok := compare(a, b); ok {
+		return c == 0
+	}
+	return reflect.DeepEqual(a, b) || strCmp && strf("%v", a) == strf("%v", b)
+}
+
+//	Type-aware ordering of `a` and `b`: `ok` is `false` if they aren't both numbers, both strings or both times.
+func compare(a, b interface{}) (c int, ok bool) {
+	if fa, isnum := number(a); isnum {
+		var fb float64
+		if fb, ok = number(b); ok {
+			ia, aint := a.(int64)
+			ib, bint := b.(int64)
+			if aint && bint {
+				c = cmpOrd(ia < ib, ia > ib)
+			} else {
+				c = cmpOrd(fa < fb, fa > fb)
+			}
+		}
+		return
+	}
+	if ta, istime := timeOf(a); istime {
+		if tb, bistime := timeOf(b); bistime {
+			c, ok = cmpOrd(ta.Before(tb), ta.After(tb)), true
+			return
+		}
+	}
+	if sa, isstr := a.(string); isstr {
+		var sb string
+		if sb, ok = b.(string); ok {
+			c = strings.Compare(sa, sb)
+		}
+	}
+	return
+}
+
+func cmpOrd(less, greater bool) int {
+	if less {
+		return -1
+	} else if greater {
+		return 1
+	}
+	return 0
+}
+
+func number(v interface{}) (f float64, ok bool) {
+	ok = true
+	switch n := v.(type) {
+	case float64:
+		f = n
+	case float32:
+		f = float64(n)
+	case int:
+		f = float64(n)
+	case int8:
+		f = float64(n)
+	case int16:
+		f = float64(n)
+	case int32:
+		f = float64(n)
+	case int64:
+		f = float64(n)
+	case uint:
+		f = float64(n)
+	case uint8:
+		f = float64(n)
+	case uint16:
+		f = float64(n)
+	case uint32:
+		f = float64(n)
+	case uint64:
+		f = float64(n)
+	default:
+		ok = false
+	}
+	return
+}
+
+//	Only a `time.Time` or, if `v` is a string, one in RFC 3339 format.
+func timeOf(v interface{}) (t time.Time, ok bool) {
+	switch tv := v.(type) {
+	case time.Time:
+		t, ok = tv, true
+	case string:
+		if len(tv) >= len("2006-01-02T15:04:05Z") && tv[4] == '-' && tv[10] == 'T' {
+			var err error
+			t, err = time.Parse(time.RFC3339Nano, tv)
+			ok = err == nil
+		}
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package fsdb
+
+import (
+	"database/sql/driver"
+	"regexp"
+	"strconv"
+)
+
+const opParam = "$param"
+
+var paramSyntax = regexp.MustCompile(`^(\$+)([1-9][0-9]*)$`)
+
+//	Returns the 1-based placeholder index if `v` is a `{"$param": n}` object, else `0`.
+func paramIndex(v interface{}) (idx int) {
+	if p := m(v); len(p) == 1 && p[opParam] != nil {
+		if idx = integer(p[opParam]); idx < 1 {
+			idx = 0
+		}
+	}
+	return
+}
+
+//	Replaces (in place) every `"$n"` string anywhere in the JSON statement value `v` by its
+//	`{"$param": n}` equivalent, and unescapes every `"$$n"` string into the literal `"$n"`
+//	(and `"$$$n"` into `"$$n"` and so on). Returns `v`, or its replacement if it's such a string.
+func parseParams(v interface{}) interface{} {
+	switch vx := v.(type) {
+	case string:
+		if parts := paramSyntax.FindStringSubmatch(vx); parts != nil {
+			if len(parts[1]) > 1 {
+				return vx[1:]
+			} else if idx, err := strconv.Atoi(parts[2]); err == nil {
+				return M{opParam: idx}
+			}
+		}
+	case []interface{}:
+		for i, x := range vx {
+			vx[i] = parseParams(x)
+		}
+	case map[string]interface{}, M:
+		for k, x := range m(vx) {
+			m(vx)[k] = parseParams(x)
+		}
+	}
+	return v
+}
+
+//	The inverse of `parseParams` for literal values: prefixes (in place) every string anywhere
+//	in `v` that `parseParams` would take for a placeholder or an escaped one with another `$`.
+func escapeParams(v interface{}) interface{} {
+	switch vx := v.(type) {
+	case string:
+		if paramSyntax.MatchString(vx) {
+			return "$" + vx
+		}
+	case []interface{}:
+		for i, x := range vx {
+			vx[i] = escapeParams(x)
+		}
+	case map[string]interface{}, M:
+		for k, x := range m(vx) {
+			m(vx)[k] = escapeParams(x)
+		}
+	}
+	return v
+}
+
+//	Returns the highest placeholder index found anywhere in `v`.
+func numParams(v interface{}) (num int) {
+	if num = paramIndex(v); num == 0 {
+		switch vx := v.(type) {
+		case []interface{}:
+			for _, x := range vx {
+				if n := numParams(x); n > num {
+					num = n
+				}
+			}
+		case map[string]interface{}, M:
+			for _, x := range m(vx) {
+				if n := numParams(x); n > num {
+					num = n
+				}
+			}
+		}
+	}
+	return
+}
+
+//	Returns a deep copy of `v` with all placeholders replaced by their `args`.
+//	Objects keep their type (`M` or `map[string]interface{}`), as do slices.
+func bindParams(v interface{}, args []driver.Value) interface{} {
+	if idx := paramIndex(v); idx > 0 && idx <= len(args) {
+		if b, ok := args[idx-1].([]byte); ok {
+			return string(b)
+		}
+		return args[idx-1]
+	}
+	switch vx := v.(type) {
+	case []interface{}:
+		sl := make([]interface{}, len(vx))
+		for i, x := range vx {
+			sl[i] = bindParams(x, args)
+		}
+		return sl
+	case map[string]interface{}:
+		mm := make(map[string]interface{}, len(vx))
+		for k, x := range vx {
+			mm[k] = bindParams(x, args)
+		}
+		return mm
+	case M:
+		mm := make(M, len(vx))
+		for k, x := range vx {
+			mm[k] = bindParams(x, args)
+		}
+		return mm
+	}
+	return v
+}
@@ -0,0 +1,58 @@
+package fsdb
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseParams(t *testing.T) {
+	for _, test := range []struct {
+		stmt, want string
+		numParams  int
+	}{
+		{`{"set": {"A": "$1", "B": {"$param": 2}}}`, `{"set": {"A": {"$param": 1}, "B": {"$param": 2}}}`, 2},
+		{`{"where": {"A": {"$in": ["x", "$3"]}}}`, `{"where": {"A": {"$in": ["x", {"$param": 3}]}}}`, 3},
+		{`{"set": {"A": "$$1", "B": "$$$12", "C": "$0", "D": "$01", "E": "$", "F": "a$1"}}`, `{"set": {"A": "$1", "B": "$$12", "C": "$0", "D": "$01", "E": "$", "F": "a$1"}}`, 0},
+		{`{"set": {"A": {"$param": 0}}}`, `{"set": {"A": {"$param": 0}}}`, 0},
+	} {
+		var query, want M
+		if err := json.Unmarshal([]byte(test.stmt), &query); err != nil {
+			t.Fatal(err)
+		} else if err = json.Unmarshal([]byte(test.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		if parseParams(query); strf("%v", query) != strf("%v", want) {
+			t.Errorf("%s: expected %v, got %v", test.stmt, want, query)
+		} else if n := numParams(query); n != test.numParams {
+			t.Errorf("%s: expected %d placeholders, got %d", test.stmt, test.numParams, n)
+		}
+	}
+}
+
+func TestEscapeParams(t *testing.T) {
+	for _, s := range []string{"$1", "$$1", "$$$12", "$0", "$01", "$", "a$1", "1"} {
+		if stmt, err := marshalStmt(M{"set": M{"S": s, "L": []string{s}}}); err != nil {
+			t.Fatal(err)
+		} else {
+			var query M
+			if err = json.Unmarshal([]byte(stmt), &query); err != nil {
+				t.Fatal(err)
+			}
+			set := m(m(parseParams(query))["set"])
+			if set["S"] != s || interfaces(set["L"])[0] != s {
+				t.Errorf("%s: expected '%s' to survive escaping and parsing, got %v", stmt, s, set)
+			}
+		}
+	}
+}
+
+func TestBindParams(t *testing.T) {
+	args := []driver.Value{"a", int64(2), []byte("c")}
+	v := bindParams(map[string]interface{}{"L": []interface{}{M{opParam: 1}, M{opParam: 3}}, "N": M{"X": M{opParam: 2}}, "P": M{opParam: 4}}, args)
+	want := map[string]interface{}{"L": []interface{}{"a", "c"}, "N": M{"X": int64(2)}, "P": M{opParam: 4}}
+	if !reflect.DeepEqual(v, want) {
+		t.Errorf("expected %#v, got %#v", want, v)
+	}
+}
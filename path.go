@@ -0,0 +1,130 @@
+package fsdb
+
+import (
+	"strconv"
+	"strings"
+)
+
+//	Returns the value at `path` in `me`, or `nil` (see `getPath`).
+func (me M) at(path string) (v interface{}) {
+	v, _ = getPath(me, path)
+	return
+}
+
+//	Returns the value at `path` in `rec`: either a top-level field of exactly that
+//	name or else a dot-separated path into nested objects and slices, such as
+//	`Address.City` or `Items.0.Sku`.
+func getPath(rec M, path string) (v interface{}, ok bool) {
+	if v, ok = rec[path]; ok || !strings.Contains(path, ".") {
+		return
+	}
+	v = rec
+	for _, part := range strings.Split(path, ".") {
+		if sl, isSlice := v.([]interface{}); isSlice {
+			i, isIdx := sliceIndex(part)
+			if !isIdx || i >= len(sl) {
+				return nil, false
+			}
+			v = sl[i]
+		} else if mm := m(v); mm != nil {
+			if v, ok = mm[part]; !ok {
+				return nil, false
+			}
+		} else {
+			return nil, false
+		}
+	}
+	return
+}
+
+//	Sets the value at `path` in `rec` (see `getPath`), creating missing objects along the
+//	way and copying (rather than modifying) all existing ones, so that `rec` can be a copy.
+//	A slice index may only address an existing element or the one just past the end (appending),
+//	and existing values along the way must be objects or (for indexes) slices, else `ErrInvalidPath`
+//	is returned and `rec` is left as-is.
+func setPath(rec M, path string, v interface{}) (err error) {
+	if _, exists := rec[path]; exists || !strings.Contains(path, ".") {
+		rec[path] = v
+	} else {
+		var val interface{}
+		parts := strings.Split(path, ".")
+		if val, err = setIn(rec[parts[0]], parts[1:], v, path); err == nil {
+			rec[parts[0]] = val
+		}
+	}
+	return
+}
+
+func setIn(cur interface{}, parts []string, v interface{}, path string) (val interface{}, err error) {
+	if len(parts) == 0 {
+		return v, nil
+	}
+	if sl, isSlice := cur.([]interface{}); isSlice {
+		if i, ok := sliceIndex(parts[0]); ok {
+			if i > len(sl) {
+				return nil, stmtErr(ErrInvalidPath, path, "index %d is past the end of the slice (of length %d)", i, len(sl))
+			}
+			cp := make([]interface{}, len(sl), len(sl)+1)
+			copy(cp, sl)
+			if i == len(cp) {
+				cp = append(cp, nil)
+			}
+			if cp[i], err = setIn(cp[i], parts[1:], v, path); err == nil {
+				val = cp
+			}
+			return
+		}
+		return nil, stmtErr(ErrInvalidPath, path, "'%s' is not an index into the slice", parts[0])
+	} else if cur != nil && m(cur) == nil {
+		return nil, stmtErr(ErrInvalidPath, path, "cannot set '%s' in %#v, which is not an object", parts[0], cur)
+	}
+	cp := M{}
+	for k, x := range m(cur) {
+		cp[k] = x
+	}
+	if cp[parts[0]], err = setIn(cp[parts[0]], parts[1:], v, path); err == nil {
+		val = cp
+	}
+	return
+}
+
+//	Removes the value at `path` from `rec` (see `getPath`), copying (rather than
+//	modifying) all objects along the way. Slice elements are set to `nil` instead.
+func unsetPath(rec M, path string) {
+	if _, exists := rec[path]; exists || !strings.Contains(path, ".") {
+		delete(rec, path)
+	} else if _, exists = getPath(rec, path); exists {
+		parts := strings.Split(path, ".")
+		rec[parts[0]] = unsetIn(rec[parts[0]], parts[1:])
+	}
+}
+
+func unsetIn(cur interface{}, parts []string) interface{} {
+	if sl, isSlice := cur.([]interface{}); isSlice {
+		i, _ := sliceIndex(parts[0])
+		cp := append([]interface{}{}, sl...)
+		if len(parts) == 1 {
+			cp[i] = nil
+		} else {
+			cp[i] = unsetIn(cp[i], parts[1:])
+		}
+		return cp
+	}
+	cp := M{}
+	for k, x := range m(cur) {
+		cp[k] = x
+	}
+	if len(parts) == 1 {
+		delete(cp, parts[0])
+	} else {
+		cp[parts[0]] = unsetIn(cp[parts[0]], parts[1:])
+	}
+	return cp
+}
+
+func sliceIndex(part string) (i int, ok bool) {
+	var err error
+	i, err = strconv.Atoi(part)
+	ok = err == nil && i >= 0
+	return
+}
@@ -0,0 +1,92 @@
+package fsdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testRec() M {
+	return M{"Name": "A", "a.b": 1, "Address": map[string]interface{}{"City": "Berlin"}, "Street": "Main St 1",
+		"Items": []interface{}{map[string]interface{}{"Sku": "x"}, 2.0}}
+}
+
+func TestGetPath(t *testing.T) {
+	for _, test := range []struct {
+		path   string
+		want   interface{}
+		wantOk bool
+	}{
+		{"Name", "A", true},
+		{"a.b", 1, true},
+		{"Address.City", "Berlin", true},
+		{"Items.0.Sku", "x", true},
+		{"Items.1", 2.0, true},
+		{"Items.2", nil, false},
+		{"Items.x", nil, false},
+		{"Address.Zip", nil, false},
+		{"Street.Number", nil, false},
+		{"Nope", nil, false},
+	} {
+		if v, ok := getPath(testRec(), test.path); ok != test.wantOk || !reflect.DeepEqual(v, test.want) {
+			t.Errorf("%s: expected %v (%v), got %v (%v)", test.path, test.want, test.wantOk, v, ok)
+		}
+	}
+}
+
+func TestSetPath(t *testing.T) {
+	for _, test := range []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "Name", want: 5},
+		{path: "a.b", want: 5},
+		{path: "Address.City", want: 5},
+		{path: "Address.Geo.Lat", want: 5},
+		{path: "Items.0.Sku", want: 5},
+		{path: "Items.2", want: 5},
+		{path: "Items.3", wantErr: true},
+		{path: "Items.99999999999", wantErr: true},
+		{path: "Items.x", wantErr: true},
+		{path: "Street.City", wantErr: true},
+		{path: "Items.1.Sku", wantErr: true},
+	} {
+		rec := testRec()
+		addr := rec["Address"].(map[string]interface{})
+		if err := setPath(rec, test.path, 5); (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.path, test.wantErr, err)
+		} else if err != nil {
+			if !reflect.DeepEqual(rec, testRec()) {
+				t.Errorf("%s: expected no change after an error, got %v", test.path, rec)
+			}
+		} else if v, _ := getPath(rec, test.path); v != test.want {
+			t.Errorf("%s: expected %v, got %v", test.path, test.want, v)
+		} else if len(addr) != 1 || addr["City"] != "Berlin" {
+			t.Errorf("%s: expected the original nested objects to stay as-is, got %v", test.path, addr)
+		}
+	}
+}
+
+func TestUnsetPath(t *testing.T) {
+	for _, test := range []struct {
+		path string
+		want M
+	}{
+		{"Name", M{"Name": nil}},
+		{"Address.City", M{"Address": M{}}},
+		{"Items.0.Sku", M{"Items": []interface{}{M{}, 2.0}}},
+		{"Items.1", M{"Items": []interface{}{map[string]interface{}{"Sku": "x"}, nil}}},
+		{"Items.5", M{}},
+		{"Street.City", M{}},
+	} {
+		rec, want := testRec(), testRec()
+		for k, v := range test.want {
+			if want[k] = v; v == nil {
+				delete(want, k)
+			}
+		}
+		if unsetPath(rec, test.path); !reflect.DeepEqual(rec, want) {
+			t.Errorf("%s: expected %v, got %v", test.path, want, rec)
+		}
+	}
+}
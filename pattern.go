@@ -0,0 +1,106 @@
+package fsdb
+
+import (
+	"regexp"
+	"strings"
+)
+
+//	Returns whether `op` is one of the string pattern operators, all of which are matched via `pattern`.
+func isPatternOp(op string) bool {
+	return op == opRegex || op == opLike || op == opPrefix || op == opContains
+}
+
+//	Compiles the operand `ov` of the pattern operator `op`, case-insensitively if `options` contains an `i`.
+func pattern(op string, ov, options interface{}) (re *regexp.Regexp, err error) {
+	if re, _ = ov.(*regexp.Regexp); re == nil {
+		expr, isStr := ov.(string)
+		if !isStr {
+			return nil, errf("expected a string, not %v", ov)
+		}
+		switch op {
+		case opLike:
+			expr = likeToRegex(expr)
+		case opPrefix:
+			expr = "^" + regexp.QuoteMeta(expr)
+		case opContains:
+			expr = regexp.QuoteMeta(expr)
+		}
+		if opts, _ := options.(string); strings.Contains(opts, "i") {
+			expr = "(?i)" + expr
+		}
+		re, err = regexp.Compile(expr)
+	}
+	return
+}
+
+//	SQL `LIKE` semantics: `%` matches any (possibly empty) text, `_` any single
+//	character, and a backslash escapes the next character. Must match the whole value.
+func likeToRegex(like string) string {
+	var buf strings.Builder
+	buf.WriteString("(?s)^")
+	for esc, rs := false, []rune(like); len(rs) > 0; rs = rs[1:] {
+		switch r := rs[0]; {
+		case esc:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+			esc = false
+		case r == '\\':
+			esc = true
+		case r == '%':
+			buf.WriteString(".*")
+		case r == '_':
+			buf.WriteString(".")
+		default:
+			buf.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	buf.WriteString("$")
+	return buf.String()
+}
+
+func matchPattern(rv interface{}, op string, ov, options interface{}, strCmp bool) bool {
+	if re, err := pattern(op, ov, options); err == nil {
+		if s, isStr := rv.(string); isStr {
+			return re.MatchString(s)
+		} else if strCmp && rv != nil {
+			return re.MatchString(strf("%v", rv))
+		}
+	}
+	return false
+}
+
+//	Replaces (in place) the operands of all pattern operators in the `where` criteria, including
+//	those of subqueries, by their compiled `*regexp.Regexp`. Operands or options that are
+//	still placeholders are left as they are.
+func compilePatterns(where M) (err error) {
+	for fn, fx := range where {
+		switch fn {
+		case opAnd, opOr, opNot:
+			for _, sub := range interfaces(fx) {
+				if err = compilePatterns(m(sub)); err != nil {
+					return
+				}
+			}
+		default:
+			if sub := subqueryOf(fx); sub != nil {
+				err = compilePatterns(m(sub["where"]))
+			} else if fops := ops(fx); fops != nil && paramIndex(fx) == 0 && paramIndex(fops[opOptions]) == 0 {
+				for op, ov := range fops {
+					if sub = subqueryOf(ov); sub != nil {
+						err = compilePatterns(m(sub["where"]))
+					} else if isPatternOp(op) && paramIndex(ov) == 0 {
+						if fops[op], err = pattern(op, ov, fops[opOptions]); err != nil {
+							err = stmtErr(ErrInvalidClause, op, "in where criteria for '%s': %s", fn, err.Error())
+						}
+					}
+					if err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
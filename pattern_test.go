@@ -0,0 +1,83 @@
+package fsdb
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestMatchPattern(t *testing.T) {
+	for _, test := range []struct {
+		op      string
+		ov      interface{}
+		options interface{}
+		rv      interface{}
+		want    bool
+	}{
+		{opLike, "a%", nil, "abc", true},
+		{opLike, "a%", nil, "Abc", false},
+		{opLike, "a%", "i", "Abc", true},
+		{opLike, "a_c", nil, "abc", true},
+		{opLike, "a_c", nil, "abbc", false},
+		{opLike, "%b%", nil, "abc", true},
+		{opLike, "b%", nil, "abc", false},
+		{opLike, "%", nil, "", true},
+		{opLike, "a%", nil, "a\nb", true},
+		{opLike, `100\%`, nil, "100%", true},
+		{opLike, `100\%`, nil, "1000", false},
+		{opLike, `a\_c`, nil, "abc", false},
+		{opLike, `a\_c`, nil, "a_c", true},
+		{opLike, `a\\c`, nil, `a\c`, true},
+		{opLike, "a.c", nil, "abc", false},
+		{opLike, "(a)+", nil, "(a)+", true},
+		{opLike, "1%", nil, 12.0, false},
+		{opRegex, "^[ab]+$", nil, "abba", true},
+		{opRegex, "^[ab]+$", nil, "ABBA", false},
+		{opRegex, "^[ab]+$", "i", "ABBA", true},
+		{opRegex, "b", nil, "abc", true},
+		{opRegex, "b", nil, nil, false},
+		{opPrefix, "a.", nil, "a.b", true},
+		{opPrefix, "a.", nil, "ab", false},
+		{opPrefix, "A", "i", "ab", true},
+		{opContains, "+1", nil, "a+1b", true},
+		{opContains, "B", nil, "abc", false},
+		{opContains, "B", "i", "abc", true},
+		{opContains, "b", nil, 1.0, false},
+		{opRegex, "(", nil, "(", false},
+		{opLike, 1, nil, "1", false},
+	} {
+		if got := matchPattern(test.rv, test.op, test.ov, test.options, false); got != test.want {
+			t.Errorf("%v %s %v (%v): expected %v, got %v", test.rv, test.op, test.ov, test.options, test.want, got)
+		}
+	}
+	if !matchPattern(12.0, opLike, "1%", nil, true) {
+		t.Error("expected non-string values to match as strings with strCmp")
+	}
+}
+
+func TestCompilePatterns(t *testing.T) {
+	where := M{"A": M{opLike: "a%", opOptions: "i"}, "B": M{opRegex: M{opParam: 1}}, "C": M{opContains: "x", opOptions: M{opParam: 2}},
+		opOr: []interface{}{M{"D": M{opPrefix: "d"}}}}
+	if err := compilePatterns(where); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		op    string
+		ov    interface{}
+		isPat bool
+	}{
+		{opLike, m(where["A"])[opLike], true},
+		{opRegex, m(where["B"])[opRegex], false},
+		{opContains, m(where["C"])[opContains], false},
+		{opPrefix, m(m(interfaces(where[opOr])[0])["D"])[opPrefix], true},
+	} {
+		if _, isPat := test.ov.(*regexp.Regexp); isPat != test.isPat {
+			t.Errorf("%s: expected compiled %v, got %#v", test.op, test.isPat, test.ov)
+		}
+	}
+	if re, _ := m(where["A"])[opLike].(*regexp.Regexp); re == nil || !re.MatchString("ABC") {
+		t.Errorf("expected a case-insensitive pattern, got %v", re)
+	}
+	if err := compilePatterns(M{"A": M{opRegex: "("}}); err == nil {
+		t.Error("expected an error for an invalid regex")
+	}
+}
@@ -0,0 +1,78 @@
+package fsdb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//	Returns the path of the file holding the record `rid` in a table stored as one file per record.
+func (me *table) recordFilePath(rid string) string {
+	return filepath.Join(me.filePath, rid+me.conn.drv.fileExt)
+}
+
+//	Like `reload` for tables stored as one file per record: (re)reads only the record files
+//	modified since the last load (or all of them if `full`) and drops the records whose files are gone.
+//	Callers lock `me` as necessary.
+func (me *table) reloadRecordFiles(full bool) (err error) {
+	var fis []os.FileInfo
+	now, ext := time.Now(), me.conn.drv.fileExt
+	if fis, err = ioutil.ReadDir(me.filePath); err == nil {
+		recs := make(M, len(fis))
+		for _, fi := range fis {
+			if fn := fi.Name(); (!fi.IsDir()) && strings.HasSuffix(fn, ext) && len(fn) > len(ext) {
+				rid := fn[:len(fn)-len(ext)]
+				if rec := m(me.recs[rid]); rec != nil && !(full || fi.ModTime().After(me.lastLoad)) {
+					recs[rid] = rec
+				} else {
+					var raw []byte
+					rec = M{}
+					if raw, err = ioutil.ReadFile(filepath.Join(me.filePath, fn)); err == nil {
+						err = me.conn.drv.unmarshal(raw, &rec)
+					}
+					if err != nil {
+						return
+					}
+					recs[rid] = rec
+				}
+			}
+		}
+		me.recs, me.touched, me.lastLoad = recs, nil, now
+		err = me.loadMeta()
+	}
+	return
+}
+
+//	Writes (or for deleted records, removes) the files of all `me.touched` records.
+func (me *table) persistRecordFiles() (err error) {
+	var raw []byte
+	for rid, _ := range me.touched {
+		if rec := m(me.recs[rid]); rec == nil {
+			if err = os.Remove(me.recordFilePath(rid)); os.IsNotExist(err) {
+				err = nil
+			}
+		} else if raw, err = me.conn.drv.marshal(rec); err == nil {
+			err = writeFileAtomic(me.recordFilePath(rid), raw)
+		}
+		if err != nil {
+			return
+		}
+		delete(me.touched, rid)
+	}
+	me.lastLoad = time.Now()
+	return
+}
+
+//	The total size of all record files of `me`.
+func (me *table) recordFilesSize() (size int64) {
+	if fis, err := ioutil.ReadDir(me.filePath); err == nil {
+		for _, fi := range fis {
+			if !fi.IsDir() {
+				size += fi.Size()
+			}
+		}
+	}
+	return
+}
@@ -3,12 +3,18 @@ package fsdb
 import (
 	"database/sql/driver"
 	"io"
+	"reflect"
+	"sort"
+	"strings"
 
 	"github.com/metaleap/go-util/slice"
 )
 
 type result struct {
 	InsertedLast, AffectedRows int64
+
+	// the affected records by `__id`, for `returning`: as inserted, as updated or as before deletion
+	recs map[string]M
 }
 
 func (me *result) LastInsertId() (id int64, err error) {
@@ -28,19 +34,172 @@ type rows struct {
 	cur  int
 }
 
+//	Returns the records in `recs`, ordered by their numeric `__id`. The
+//	`cols` aren't set until `project` is called.
 func newRows(recs map[string]M) (me *rows) {
 	me = &rows{recs: make([]M, 0, len(recs)), rids: make([]string, 0, len(recs))}
-	me.cols = append(me.cols, IdField)
-	for rid, rec := range recs {
-		for cn, _ := range rec {
-			uslice.StrAppendUnique(&me.cols, cn)
-		}
-		me.recs = append(me.recs, rec)
+	for rid, _ := range recs {
 		me.rids = append(me.rids, rid)
 	}
+	sort.Slice(me.rids, func(i, j int) bool { return CompareIds(me.rids[i], me.rids[j]) < 0 })
+	for _, rid := range me.rids {
+		me.recs = append(me.recs, recs[rid])
+	}
 	return
 }
 
+//	Returns one row with one column `col` having the value `val`.
+func singleRow(col string, val interface{}) *rows {
+	return &rows{cols: []string{col}, rids: []string{"0"}, recs: []M{{col: val}}}
+}
+
+//	Stable-sorts `me` by the `orderBy` fields, each optionally suffixed with ` asc` or ` desc`.
+//	Records that lack an `orderBy` field always sort after those that have it.
+func (me *rows) orderBy(orderBy []string) {
+	type orderKey struct {
+		field string
+		desc  bool
+	}
+	keys := make([]orderKey, 0, len(orderBy))
+	for _, ob := range orderBy {
+		if parts := strings.Fields(ob); len(parts) > 0 {
+			keys = append(keys, orderKey{field: parts[0], desc: len(parts) > 1 && strings.ToLower(parts[1]) == "desc"})
+		}
+	}
+	if len(keys) > 0 {
+		sort.Stable(&rowsSorter{rows: me, less: func(i, j int) bool {
+			for _, k := range keys {
+				var c int
+				if k.field == IdField {
+					c = CompareIds(me.rids[i], me.rids[j])
+				} else {
+					vi, vj := me.recs[i].at(k.field), me.recs[j].at(k.field)
+					if (vi == nil) != (vj == nil) {
+						return vj == nil
+					}
+					c = cmpAny(vi, vj)
+				}
+				if c != 0 {
+					return (c < 0) != k.desc
+				}
+			}
+			return false
+		}})
+	}
+}
+
+//	Replaces the records in `me` by the unique combinations of their values for `fields`, sorted by
+//	those. Slice values are flattened: a record contributes one combination per element (and for
+//	multiple slice-valued `fields`, per combination of elements), or none for an empty slice.
+func (me *rows) distinct(fields []string) {
+	var combos []M
+	seen := map[string]bool{}
+	for _, rec := range me.recs {
+		recCombos := []M{{}}
+		for _, fn := range fields {
+			vals := []interface{}{rec.at(fn)}
+			if rv := reflect.ValueOf(vals[0]); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+				vals = make([]interface{}, rv.Len())
+				for i := range vals {
+					vals[i] = rv.Index(i).Interface()
+				}
+			}
+			next := make([]M, 0, len(recCombos)*len(vals))
+			for _, combo := range recCombos {
+				for _, v := range vals {
+					c := M{fn: v}
+					for k, cv := range combo {
+						c[k] = cv
+					}
+					next = append(next, c)
+				}
+			}
+			recCombos = next
+		}
+		for _, combo := range recCombos {
+			keys := make([]string, len(fields))
+			for i, fn := range fields {
+				keys[i] = strf("%T:%v", combo[fn], combo[fn])
+			}
+			if key := strings.Join(keys, "\x00"); !seen[key] {
+				seen[key], combos = true, append(combos, combo)
+			}
+		}
+	}
+	sort.Slice(combos, func(i, j int) bool {
+		for _, fn := range fields {
+			if c := cmpAny(combos[i][fn], combos[j][fn]); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+	me.rids, me.recs = make([]string, len(combos)), combos
+	for i := range me.rids {
+		me.rids[i] = strf("%d", i)
+	}
+}
+
+//	Skips the first `offset` records, then drops all but `limit` records. `0` means none.
+func (me *rows) page(limit, offset int) {
+	if offset >= len(me.recs) {
+		me.rids, me.recs = nil, nil
+	} else if offset > 0 {
+		me.rids, me.recs = me.rids[offset:], me.recs[offset:]
+	}
+	if limit > 0 && limit < len(me.recs) {
+		me.rids, me.recs = me.rids[:limit], me.recs[:limit]
+	}
+}
+
+//	Sets the `cols` of `me` to exactly `fields` if any, or else to `__id` plus (sorted by name)
+//	every field found in any of the records. Either way, columns named in `exclude` are omitted.
+func (me *rows) project(fields, exclude []string) {
+	if me.cols = nil; len(fields) == 0 {
+		me.cols = append(me.cols, IdField)
+		for _, rec := range me.recs {
+			for cn, _ := range rec {
+				uslice.StrAppendUnique(&me.cols, cn)
+			}
+		}
+		sort.Strings(me.cols[1:])
+	}
+	for _, cn := range fields {
+		uslice.StrAppendUnique(&me.cols, cn)
+	}
+	if len(exclude) > 0 {
+		cols := me.cols[:0]
+		for _, cn := range me.cols {
+			if !uslice.StrHas(exclude, cn) {
+				cols = append(cols, cn)
+			}
+		}
+		me.cols = cols
+	}
+}
+
+type rowsSorter struct {
+	*rows
+	less func(int, int) bool
+}
+
+func (me *rowsSorter) Len() int           { return len(me.recs) }
+func (me *rowsSorter) Less(i, j int) bool { return me.less(i, j) }
+func (me *rowsSorter) Swap(i, j int) {
+	me.rids[i], me.rids[j] = me.rids[j], me.rids[i]
+	me.recs[i], me.recs[j] = me.recs[j], me.recs[i]
+}
+
+//	Like `compare`, but never fails: values of different kinds are ordered by their type names.
+func cmpAny(a, b interface{}) int {
+	if c, ok := compare(a, b); ok {
+		return c
+	} else if ta, tb := strf("%T", a), strf("%T", b); ta != tb {
+		return strings.Compare(ta, tb)
+	}
+	return strings.Compare(strf("%v", a), strf("%v", b))
+}
+
 func (me *rows) Columns() []string {
 	return me.cols
 }
@@ -58,10 +217,10 @@ func (me *rows) Next(dest []driver.Value) (err error) {
 			for ci, cn := range me.cols {
 				if cn == IdField {
 					dest[ci] = me.rids[me.cur]
-				} else if str, ok = rec[cn].(string); ok {
+				} else if str, ok = rec.at(cn).(string); ok {
 					dest[ci] = []byte(str)
 				} else {
-					dest[ci] = rec[cn]
+					dest[ci] = rec.at(cn)
 				}
 			}
 		}
@@ -0,0 +1,404 @@
+package fsdb
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+//	A single lexical token of a statement in the `SqlDialect`.
+type sqlToken struct {
+	pos  int
+	kind byte // 'i'dentifier, 'q'uoted identifier, 's'tring, 'n'umber, 'p'laceholder, or else the symbol itself ('(' or '<' etc.)
+	text string
+}
+
+type sqlParser struct {
+	toks      []sqlToken
+	cur       int
+	numParams int
+}
+
+//	Compiles a statement in the `SqlDialect` into the same form as a JSON statement.
+func parseSql(query string) (stmt M, err error) {
+	var me sqlParser
+	if me.toks, err = sqlTokenize(query); err == nil {
+		defer func() {
+			if r := recover(); r != nil {
+				if e, ok := r.(sqlError); ok {
+					stmt, err = nil, e.error
+				} else {
+					panic(r)
+				}
+			}
+		}()
+		stmt = me.statement()
+	}
+	return
+}
+
+//	Only used to `panic` out of the recursive-descent `sqlParser` (and `recover` in `parseSql`).
+type sqlError struct{ error }
+
+func (me *sqlParser) fail(format string, args ...interface{}) {
+	at := "end of statement"
+	if me.cur < len(me.toks) {
+		at = strf("offset %d", me.toks[me.cur].pos)
+	}
+	panic(sqlError{stmtErr(ErrSyntax, "", "at %s: %s", at, strf(format, args...))})
+}
+
+func (me *sqlParser) peek() (tok sqlToken) {
+	if me.cur < len(me.toks) {
+		tok = me.toks[me.cur]
+	}
+	return
+}
+
+//	Returns whether the next token is the keyword or symbol `kw` (case-insensitively), and if so, consumes it.
+func (me *sqlParser) accept(kw string) (ok bool) {
+	tok := me.peek()
+	if ok = tok.kind != 0 && tok.kind != 'q' && tok.kind != 's' && tok.kind != 'n' && tok.kind != 'p' && strings.EqualFold(tok.text, kw); ok {
+		me.cur++
+	}
+	return
+}
+
+func (me *sqlParser) expect(kws ...string) {
+	for _, kw := range kws {
+		if !me.accept(kw) {
+			me.fail("expected %s", strings.ToUpper(kw))
+		}
+	}
+}
+
+func (me *sqlParser) ident() (name string) {
+	if tok := me.peek(); tok.kind == 'i' || tok.kind == 'q' {
+		name = tok.text
+		me.cur++
+	} else {
+		me.fail("expected a name")
+	}
+	return
+}
+
+func (me *sqlParser) identList() (names []string) {
+	for names = append(names, me.ident()); me.accept(","); {
+		names = append(names, me.ident())
+	}
+	return
+}
+
+func (me *sqlParser) statement() (stmt M) {
+	switch {
+	case me.accept("create"):
+		me.expect("table")
+		stmt = M{cmdCreateTable: me.ident()}
+	case me.accept("drop"):
+		me.expect("table")
+		stmt = M{cmdDropTable: me.ident()}
+	case me.accept("insert"):
+		me.expect("into")
+		stmt = M{cmdInsertInto: me.ident()}
+		me.expect("(")
+		cols := me.identList()
+		me.expect(")", "values", "(")
+		set := M{}
+		for i, col := range cols {
+			if i > 0 {
+				me.expect(",")
+			}
+			set[col] = me.value()
+		}
+		me.expect(")")
+		stmt["set"] = set
+		me.returning(stmt)
+	case me.accept("select"):
+		if next := me.cur + 1; next < len(me.toks) && me.toks[next].kind == '(' && me.accept("count") {
+			me.expect("(", "*", ")", "from")
+			stmt = M{cmdCount: me.ident()}
+			me.where(stmt)
+			break
+		}
+		var fields []string
+		distinct := me.accept("distinct")
+		if distinct || !me.accept("*") {
+			fields = me.identList()
+		}
+		me.expect("from")
+		stmt = M{cmdSelectFrom: me.ident()}
+		if distinct {
+			stmt["distinct"] = fields
+		} else if len(fields) > 0 {
+			stmt["fields"] = fields
+		}
+		me.where(stmt)
+		if me.accept("order") {
+			me.expect("by")
+			var orderBy []string
+			for {
+				ob := me.ident()
+				if me.accept("desc") {
+					ob += " desc"
+				} else {
+					me.accept("asc")
+				}
+				if orderBy = append(orderBy, ob); !me.accept(",") {
+					break
+				}
+			}
+			stmt["orderBy"] = orderBy
+		}
+		if me.accept("limit") {
+			stmt["limit"] = me.value()
+			if me.accept("offset") {
+				stmt["offset"] = me.value()
+			}
+		}
+	case me.accept("update"):
+		stmt = M{cmdUpdateWhere: me.ident()}
+		me.expect("set")
+		set := M{}
+		for {
+			col := me.ident()
+			me.expect("=")
+			if set[col] = me.value(); !me.accept(",") {
+				break
+			}
+		}
+		stmt["set"] = set
+		me.where(stmt)
+		me.returning(stmt)
+	case me.accept("delete"):
+		me.expect("from")
+		stmt = M{cmdDeleteFrom: me.ident()}
+		me.where(stmt)
+		me.returning(stmt)
+	default:
+		me.fail("expected CREATE, DROP, INSERT, SELECT, UPDATE or DELETE")
+	}
+	me.accept(";")
+	if me.cur < len(me.toks) {
+		me.fail("unexpected '%s'", me.peek().text)
+	}
+	return
+}
+
+func (me *sqlParser) where(stmt M) {
+	if me.accept("where") {
+		stmt["where"] = me.or()
+	}
+}
+
+func (me *sqlParser) returning(stmt M) {
+	if me.accept("returning") {
+		if me.accept("*") {
+			stmt["returning"] = true
+		} else {
+			stmt["returning"] = me.identList()
+		}
+	}
+}
+
+func (me *sqlParser) or() (filter M) {
+	var ors []interface{}
+	for ors = append(ors, me.and()); me.accept("or"); {
+		ors = append(ors, me.and())
+	}
+	if filter = m(ors[0]); len(ors) > 1 {
+		filter = M{opOr: ors}
+	}
+	return
+}
+
+func (me *sqlParser) and() (filter M) {
+	var ands []interface{}
+	for ands = append(ands, me.not()); me.accept("and"); {
+		ands = append(ands, me.not())
+	}
+	if filter = m(ands[0]); len(ands) > 1 {
+		filter = M{opAnd: ands}
+	}
+	return
+}
+
+func (me *sqlParser) not() (filter M) {
+	if me.accept("not") {
+		filter = M{opNot: me.not()}
+	} else if me.accept("(") {
+		filter = me.or()
+		me.expect(")")
+	} else {
+		filter = me.comparison()
+	}
+	return
+}
+
+func (me *sqlParser) comparison() (filter M) {
+	col := me.ident()
+	var op string
+	switch {
+	case me.accept("="):
+		op = opEq
+	case me.accept("!="), me.accept("<>"):
+		op = opNe
+	case me.accept("<="):
+		op = opLte
+	case me.accept(">="):
+		op = opGte
+	case me.accept("<"):
+		op = opLt
+	case me.accept(">"):
+		op = opGt
+	case me.accept("is"):
+		if op = opEq; me.accept("not") {
+			op = opNe
+		}
+		me.expect("null")
+		return M{col: M{op: nil}}
+	case me.accept("not"):
+		if like := me.like(); like != nil {
+			return M{opNot: M{col: like}}
+		}
+		me.expect("in")
+		return M{col: M{opNin: me.valuesOrSubquery()}}
+	case me.accept("in"):
+		return M{col: M{opIn: me.valuesOrSubquery()}}
+	default:
+		if like := me.like(); like != nil {
+			return M{col: like}
+		}
+		me.fail("expected a comparison operator after '%s'", col)
+	}
+	return M{col: M{op: me.value()}}
+}
+
+//	Parses `LIKE val` or `ILIKE val` if next, else returns `nil`.
+func (me *sqlParser) like() (like M) {
+	if me.accept("like") {
+		like = M{opLike: me.value()}
+	} else if me.accept("ilike") {
+		like = M{opLike: me.value(), opOptions: "i"}
+	}
+	return
+}
+
+//	Either a `valueList` or `(SELECT col FROM t [WHERE cond])`.
+func (me *sqlParser) valuesOrSubquery() (vals interface{}) {
+	if next := me.cur + 1; next < len(me.toks) && me.peek().kind == '(' && me.toks[next].kind == 'i' && strings.EqualFold(me.toks[next].text, "select") {
+		me.expect("(", "select")
+		field := me.ident()
+		me.expect("from")
+		sub := M{cmdSelectFrom: me.ident(), "field": field}
+		me.where(sub)
+		me.expect(")")
+		return sub
+	}
+	return me.valueList()
+}
+
+func (me *sqlParser) valueList() (vals []interface{}) {
+	me.expect("(")
+	for vals = append(vals, me.value()); me.accept(","); {
+		vals = append(vals, me.value())
+	}
+	me.expect(")")
+	return
+}
+
+func (me *sqlParser) value() (v interface{}) {
+	tok := me.peek()
+	switch tok.kind {
+	case 's':
+		v = tok.text
+	case 'n':
+		var err error
+		if v, err = strconv.ParseFloat(tok.text, 64); err != nil {
+			me.fail("invalid number '%s'", tok.text)
+		}
+	case 'p':
+		idx := me.numParams + 1
+		if tok.text != "?" {
+			if n, err := strconv.Atoi(tok.text[1:]); err != nil || n < 1 {
+				me.fail("invalid placeholder '%s'", tok.text)
+			} else {
+				idx = n
+			}
+		}
+		if idx > me.numParams {
+			me.numParams = idx
+		}
+		v = M{opParam: idx}
+	case 'i':
+		switch strings.ToLower(tok.text) {
+		case "null":
+			v = nil
+		case "true":
+			v = true
+		case "false":
+			v = false
+		default:
+			me.fail("expected a value, not '%s'", tok.text)
+		}
+	default:
+		me.fail("expected a value")
+	}
+	me.cur++
+	return
+}
+
+func sqlTokenize(query string) (toks []sqlToken, err error) {
+	src := []rune(query)
+	for i := 0; i < len(src) && err == nil; {
+		r, start := src[i], i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '\'' || r == '"' || r == '`':
+			var buf []rune
+			for i++; i < len(src); i++ {
+				if src[i] == r {
+					if i+1 < len(src) && src[i+1] == r {
+						i++
+					} else {
+						break
+					}
+				}
+				buf = append(buf, src[i])
+			}
+			if i >= len(src) {
+				err = stmtErr(ErrSyntax, "", "at offset %d: unterminated %c", start, r)
+			} else {
+				toks = append(toks, sqlToken{pos: start, kind: map[rune]byte{'\'': 's', '"': 'q', '`': 'q'}[r], text: string(buf)})
+				i++
+			}
+		case unicode.IsDigit(r) || (r == '-' && i+1 < len(src) && unicode.IsDigit(src[i+1])):
+			for i++; i < len(src) && (unicode.IsDigit(src[i]) || src[i] == '.' || src[i] == 'e' || src[i] == 'E' ||
+				((src[i] == '+' || src[i] == '-') && (src[i-1] == 'e' || src[i-1] == 'E'))); i++ {
+			}
+			toks = append(toks, sqlToken{pos: start, kind: 'n', text: string(src[start:i])})
+		case unicode.IsLetter(r) || r == '_':
+			for i++; i < len(src) && (unicode.IsLetter(src[i]) || unicode.IsDigit(src[i]) || src[i] == '_' || src[i] == '.'); i++ {
+			}
+			toks = append(toks, sqlToken{pos: start, kind: 'i', text: string(src[start:i])})
+		case r == '?' || (r == '$' && i+1 < len(src) && unicode.IsDigit(src[i+1])):
+			for i++; i < len(src) && unicode.IsDigit(src[i]); i++ {
+			}
+			toks = append(toks, sqlToken{pos: start, kind: 'p', text: string(src[start:i])})
+		case strings.ContainsRune("(),*;=", r):
+			i++
+			toks = append(toks, sqlToken{pos: start, kind: byte(r), text: string(r)})
+		case r == '<' || r == '>' || r == '!':
+			if i++; i < len(src) && (src[i] == '=' || (r == '<' && src[i] == '>')) {
+				i++
+			} else if r == '!' {
+				err = stmtErr(ErrSyntax, "", "at offset %d: expected !=", start)
+			}
+			toks = append(toks, sqlToken{pos: start, kind: byte(r), text: string(src[start:i])})
+		default:
+			err = stmtErr(ErrSyntax, "", "at offset %d: unexpected '%c'", start, r)
+		}
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package fsdb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSqlTokenize(t *testing.T) {
+	for _, test := range []struct {
+		query   string
+		want    []sqlToken
+		wantErr bool
+	}{
+		{query: `a_1.b 'it''s' "x y" ` + "`z`", want: []sqlToken{{0, 'i', "a_1.b"}, {6, 's', "it's"}, {14, 'q', "x y"}, {20, 'q', "z"}}},
+		{query: `1 -2.5 1e3 1e-3 2E+10 -1.5e-2`, want: []sqlToken{{0, 'n', "1"}, {2, 'n', "-2.5"}, {7, 'n', "1e3"}, {11, 'n', "1e-3"}, {16, 'n', "2E+10"}, {22, 'n', "-1.5e-2"}}},
+		{query: `? $1 $12`, want: []sqlToken{{0, 'p', "?"}, {2, 'p', "$1"}, {5, 'p', "$12"}}},
+		{query: `(),*;= < <= <> > >= !=`, want: []sqlToken{{0, '(', "("}, {1, ')', ")"}, {2, ',', ","}, {3, '*', "*"}, {4, ';', ";"}, {5, '=', "="},
+			{7, '<', "<"}, {9, '<', "<="}, {12, '<', "<>"}, {15, '>', ">"}, {17, '>', ">="}, {20, '!', "!="}}},
+		{query: `'open`, wantErr: true},
+		{query: `a ! b`, wantErr: true},
+		{query: `a # b`, wantErr: true},
+	} {
+		if toks, err := sqlTokenize(test.query); (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.query, test.wantErr, err)
+		} else if err == nil && !reflect.DeepEqual(toks, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.want, toks)
+		}
+	}
+}
+
+func TestParseSql(t *testing.T) {
+	for _, test := range []struct {
+		query   string
+		want    M
+		wantErr bool
+	}{
+		{query: `CREATE TABLE T`, want: M{cmdCreateTable: "T"}},
+		{query: `drop table "T x";`, want: M{cmdDropTable: "T x"}},
+		{query: `INSERT INTO T (A, B, C, D) VALUES ('x', 1.5e-3, NULL, ?)`,
+			want: M{cmdInsertInto: "T", "set": M{"A": "x", "B": 1.5e-3, "C": nil, "D": M{opParam: 1}}}},
+		{query: `SELECT * FROM T`, want: M{cmdSelectFrom: "T"}},
+		{query: `SELECT A, B FROM T WHERE A = 1 AND (B > $2 OR NOT C <= ?) ORDER BY A DESC, B ASC LIMIT 10 OFFSET 5`,
+			want: M{cmdSelectFrom: "T", "fields": []string{"A", "B"}, "orderBy": []string{"A desc", "B"}, "limit": 10.0, "offset": 5.0,
+				"where": M{opAnd: []interface{}{M{"A": M{opEq: 1.0}}, M{opOr: []interface{}{M{"B": M{opGt: M{opParam: 2}}}, M{opNot: M{"C": M{opLte: M{opParam: 3}}}}}}}}}},
+		{query: `SELECT * FROM T WHERE A IS NULL OR B IS NOT NULL OR C <> 'x' OR D IN (1, 2) OR E NOT IN ('a')`,
+			want: M{cmdSelectFrom: "T", "where": M{opOr: []interface{}{M{"A": M{opEq: nil}}, M{"B": M{opNe: nil}}, M{"C": M{opNe: "x"}},
+				M{"D": M{opIn: []interface{}{1.0, 2.0}}}, M{"E": M{opNin: []interface{}{"a"}}}}}}},
+		{query: `UPDATE T SET A = TRUE, B = false WHERE C = -1`,
+			want: M{cmdUpdateWhere: "T", "set": M{"A": true, "B": false}, "where": M{"C": M{opEq: -1.0}}}},
+		{query: `DELETE FROM T`, want: M{cmdDeleteFrom: "T"}},
+		{query: `DELETE FROM T WHERE A IN (SELECT B FROM U WHERE C NOT IN (SELECT __id FROM V))`,
+			want: M{cmdDeleteFrom: "T", "where": M{"A": M{opIn: M{cmdSelectFrom: "U", "field": "B", "where": M{"C": M{opNin: M{cmdSelectFrom: "V", "field": "__id"}}}}}}}},
+		{query: `SELECT * FROM T WHERE A = $0`, wantErr: true},
+		{query: `SELECT * FROM T WHERE A = $99999999999999999999`, wantErr: true},
+		{query: `SELECT * FROM T WHERE A = 1e`, wantErr: true},
+		{query: `SELECT * FROM T WHERE A = 1.2.3`, wantErr: true},
+		{query: `SELECT * FROM T WHERE A`, wantErr: true},
+		{query: `SELECT * FROM T WHERE A = B`, wantErr: true},
+		{query: `SELECT FROM T`, wantErr: true},
+		{query: `INSERT INTO T (A, B) VALUES (1)`, wantErr: true},
+		{query: `DELETE FROM T extra`, wantErr: true},
+		{query: `MERGE INTO T`, wantErr: true},
+	} {
+		if stmt, err := parseSql(test.query); (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.query, test.wantErr, err)
+		} else if err == nil && !reflect.DeepEqual(stmt, test.want) {
+			t.Errorf("%s: expected %v, got %v", test.query, test.want, stmt)
+		}
+	}
+}
@@ -4,28 +4,48 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"strings"
+	"unicode"
 )
 
 type stmt struct {
 	conn       *conn
 	query      M
 	cmd, table string
+	numInput   int
+}
+
+type selectQuery struct {
+	where                    M
+	fields, exclude, orderBy []string
+	distinct                 []string
+	limit, offset            int
+	joins                    []join
 }
 
 func newStmt(conn *conn, query string) (me *stmt, err error) {
 	me = &stmt{conn: conn}
-	if !strings.HasPrefix(query, "{") {
-		query = "{" + query
-	}
-	if !strings.HasSuffix(query, "}") {
-		query = query + "}"
+	if query = strings.TrimSpace(query); SqlDialect && query != "" && unicode.IsLetter(rune(query[0])) {
+		me.query, err = parseSql(query)
+	} else {
+		if !strings.HasPrefix(query, "{") {
+			query = "{" + query
+		}
+		if !strings.HasSuffix(query, "}") {
+			query = query + "}"
+		}
+		if err = json.Unmarshal([]byte(query), &me.query); err == nil {
+			parseParams(me.query)
+		}
 	}
-	if err = json.Unmarshal([]byte(query), &me.query); err == nil {
+	if err != nil {
+		if _, isStmtErr := err.(*StmtError); !isStmtErr {
+			err = stmtErr(ErrSyntax, "", "%s", err.Error())
+		}
+	} else if me.cmd, me.table, err = validateStmt(me.query); err == nil {
+		err = compilePatterns(m(me.query["where"]))
 		for k, v := range me.query {
-			switch k {
-			case cmdCreateTable, cmdDropTable, cmdInsertInto, cmdSelectFrom, cmdUpdateWhere, cmdDeleteFrom:
-				me.cmd, me.table = k, strf("%v", v)
-				break // for
+			if n := numParams(v); k != me.cmd && n > me.numInput {
+				me.numInput = n
 			}
 		}
 	}
@@ -35,27 +55,75 @@ func newStmt(conn *conn, query string) (me *stmt, err error) {
 	return
 }
 
+//	Returns a copy of `me.query` with all `{"$param": n}` placeholders replaced by their `args`.
+//	(Always a copy, since `insertInto` stores its `set` record as-is and the `stmt` may be re-executed.)
+func (me *stmt) bind(args []driver.Value) (query M) {
+	query = M{}
+	for k, v := range me.query {
+		if k == me.cmd {
+			query[k] = v
+		} else {
+			query[k] = bindParams(v, args)
+		}
+	}
+	return
+}
+
+//	Compiles the patterns whose placeholders were just bound, then evaluates all subqueries.
+func (me *stmt) prepareWhere(where M) (err error) {
+	if err = compilePatterns(where); err == nil {
+		err = me.conn.resolveSubqueries(where)
+	}
+	return
+}
+
+func selectQueryOf(query M) (q *selectQuery, err error) {
+	q = &selectQuery{where: m(query["where"]), fields: strs(query["fields"]), exclude: strs(query["exclude"])}
+	q.orderBy, q.limit, q.offset = strs(query["orderBy"]), integer(query["limit"]), integer(query["offset"])
+	q.distinct = strs(query["distinct"])
+	q.joins, err = newJoins(query["join"])
+	return
+}
+
 func (me *stmt) Close() (err error) {
 	me.query = nil
 	return
 }
 
 func (me *stmt) NumInput() (num int) {
+	num = me.numInput
 	return
 }
 
 func (me *stmt) Exec(args []driver.Value) (res driver.Result, err error) {
+	defer me.conn.UnlockIf(me.conn.LockIf(me.conn.drv.ConnectionCaching()))
+	query := me.bind(args)
+	if err = me.prepareWhere(m(query["where"])); err == nil {
+		res, err = me.exec(query)
+	}
+	return
+}
+
+func (me *stmt) exec(query M) (res driver.Result, err error) {
 	switch me.cmd {
 	case cmdCreateTable:
-		err = me.conn.doCreateTable(me.table)
+		err = me.conn.doCreateTable(me.table, query["idStrategy"])
 	case cmdDropTable:
 		err = me.conn.doDropTable(me.table)
+	case cmdRenameTable:
+		err = me.conn.doRenameTable(me.table, query["to"])
+	case cmdTruncateTable:
+		res, err = me.conn.doTruncateTable(me.table)
+	case cmdAlterTable:
+		res, err = me.conn.doAlterTable(me.table, query["renameFields"], query["dropFields"], query["addFields"])
 	case cmdInsertInto:
-		res, err = me.conn.doInsertInto(me.table, me.query["set"])
+		res, err = me.conn.doInsertInto(me.table, query["set"])
 	case cmdDeleteFrom:
-		res, err = me.conn.doDeleteFrom(me.table, me.query["where"])
+		res, err = me.conn.doDeleteFrom(me.table, query["where"])
 	case cmdUpdateWhere:
-		res, err = me.conn.doUpdateWhere(me.table, me.query["set"], me.query["where"])
+		res, err = me.conn.doUpdateWhere(me.table, query["set"], query["where"])
+	case cmdUpsertInto:
+		res, err = me.conn.doUpsertInto(me.table, query["set"], query["on"])
 	default:
 		err = errf("Cannot Exec() via '%s', try Query()", me.cmd)
 	}
@@ -66,9 +134,42 @@ func (me *stmt) Exec(args []driver.Value) (res driver.Result, err error) {
 }
 
 func (me *stmt) Query(args []driver.Value) (res driver.Rows, err error) {
+	defer me.conn.UnlockIf(me.conn.LockIf(me.conn.drv.ConnectionCaching()))
+	query := me.bind(args)
+	if err = me.prepareWhere(m(query["where"])); err != nil {
+		return
+	}
 	switch me.cmd {
 	case cmdSelectFrom:
-		res, err = me.conn.doSelectFrom(me.table, me.query["where"])
+		var q *selectQuery
+		if q, err = selectQueryOf(query); err == nil {
+			res, err = me.conn.doSelectFrom(me.table, q)
+		}
+	case cmdAggregate:
+		res, err = me.conn.doAggregate(me.table, query["where"], query["groupBy"], query["aggregates"])
+	case cmdCount:
+		res, err = me.conn.doCount(me.table, query["where"])
+	case cmdExists:
+		res, err = me.conn.doExists(me.table, query["where"])
+	case cmdListTables:
+		res, err = me.conn.doListTables(me.table)
+	case cmdDescribeTable:
+		res, err = me.conn.doDescribeTable(me.table)
+	case cmdInsertInto, cmdUpdateWhere, cmdDeleteFrom:
+		if returning := query["returning"]; returning == nil || returning == false {
+			err = errf("Cannot Query() via '%s' without `returning`, try Exec()", me.cmd)
+		} else {
+			var r driver.Result
+			if r, err = me.exec(query); err == nil {
+				var fields []string
+				if returning != true {
+					fields = strs(returning)
+				}
+				affected := newRows(r.(*result).recs)
+				affected.project(fields, nil)
+				res = affected
+			}
+		}
 	default:
 		err = errf("Cannot Query() via '%s', try Exec()", me.cmd)
 	}
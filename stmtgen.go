@@ -1,17 +1,28 @@
 package fsdb
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"reflect"
 )
 
 const (
-	cmdCreateTable = "createTable"
-	cmdDropTable   = "dropTable"
-	cmdInsertInto  = "insertInto"
-	cmdSelectFrom  = "selectFrom"
-	cmdUpdateWhere = "updateWhere"
-	cmdDeleteFrom  = "deleteFrom"
+	cmdCreateTable   = "createTable"
+	cmdDropTable     = "dropTable"
+	cmdInsertInto    = "insertInto"
+	cmdSelectFrom    = "selectFrom"
+	cmdUpdateWhere   = "updateWhere"
+	cmdDeleteFrom    = "deleteFrom"
+	cmdAggregate     = "aggregate"
+	cmdUpsertInto    = "upsertInto"
+	cmdRenameTable   = "renameTable"
+	cmdTruncateTable = "truncateTable"
+	cmdAlterTable    = "alterTable"
+	cmdListTables    = "listTables"
+	cmdDescribeTable = "describeTable"
+	cmdCount         = "count"
+	cmdExists        = "exists"
 )
 
 func errf(format string, args ...interface{}) error {
@@ -22,38 +33,188 @@ func strf(format string, args ...interface{}) string {
 	return fmt.Sprintf(format, args...)
 }
 
-func genStmt(cmd, name string, set, where M) string {
+func genStmt(cmd, name string, set, where M, clauses M) string {
 	M := M{cmd: name, "set": set, "where": where}
-	raw, _ := json.Marshal(M) // marshaling a map won't err except for brute-force malfeasance
-	return string(raw)
+	for k, v := range clauses {
+		if v != nil && !reflect.ValueOf(v).IsZero() {
+			M[k] = v
+		}
+	}
+	stmt, _ := marshalStmt(M) // marshaling a map won't err except for brute-force malfeasance
+	return stmt
+}
+
+//	Marshals the generated statement `query`, whose string values are all literals, not
+//	placeholders: so those looking like one are escaped (see `escapeParams`).
+func marshalStmt(query M) (stmt string, err error) {
+	var (
+		raw []byte
+		v   interface{}
+	)
+	if raw, err = json.Marshal(query); err == nil {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.UseNumber()
+		if err = dec.Decode(&v); err == nil {
+			if raw, err = json.Marshal(escapeParams(v)); err == nil {
+				stmt = string(raw)
+			}
+		}
+	}
+	return
 }
 
 //	Generates a `{"createTable":name}` statement.
 func StmtCreateTable(name string) string {
-	return genStmt(cmdCreateTable, name, nil, nil)
+	return genStmt(cmdCreateTable, name, nil, nil, nil)
+}
+
+//	Generates a `{"createTable":name, "idStrategy": ids}` statement. The `IdStrategy` is
+//	stored in the table's ".meta" sidecar file, together with its `IdSeq` counter.
+//
+//	Regardless of it, `insertInto` uses the `__id` in its `set` if there is one. `LastInsertId`
+//	is the new `__id` if numeric, else `-1`.
+func StmtCreateTableIds(name string, ids IdStrategy) string {
+	return genStmt(cmdCreateTable, name, nil, nil, M{"idStrategy": ids})
 }
 
 //	Generates a `{"dropTable":name}` statement.
 func StmtDropTable(name string) string {
-	return genStmt(cmdDropTable, name, nil, nil)
+	return genStmt(cmdDropTable, name, nil, nil, nil)
+}
+
+//	Generates a `{"renameTable":name, "to": newName}` statement.
+func StmtRenameTable(name, newName string) string {
+	return genStmt(cmdRenameTable, name, nil, nil, M{"to": newName})
+}
+
+//	Generates a `{"truncateTable":name}` statement, which deletes all records.
+func StmtTruncateTable(name string) string {
+	return genStmt(cmdTruncateTable, name, nil, nil, nil)
+}
+
+//	Generates a `{"alterTable":name, "renameFields": renameFields, "dropFields": dropFields, "addFields": addFields}` statement.
+//
+//	In every record, first renames the fields named by the keys in `renameFields` to their values,
+//	then removes the `dropFields`, then adds the fields in `addFields` (with their values as defaults)
+//	wherever they're missing. Field names may be dot-paths (see `M`). `RowsAffected` is the number of
+//	records changed.
+func StmtAlterTable(name string, renameFields M, dropFields []string, addFields M) string {
+	return genStmt(cmdAlterTable, name, nil, nil, M{"renameFields": renameFields, "dropFields": dropFields, "addFields": addFields})
+}
+
+//	Generates a `{"listTables":pattern}` statement, for use with `Query`. Its rows (sorted by `name`)
+//	have the columns `name`, `records`, `fileSize`, `modified` and `lastLoad` for every table whose
+//	name matches the `pattern` (in `filepath.Match` syntax, so `"*"` for all tables).
+func StmtListTables(pattern string) string {
+	return genStmt(cmdListTables, pattern, nil, nil, nil)
+}
+
+//	Generates a `{"describeTable":name}` statement, for use with `Query`. Its rows have the columns
+//	`records`, `fileSize`, `modified`, `lastLoad` (the same in every row), `field`, `type` and
+//	`count`: one row for every top-level field and Go type (`%T`) of its values, sorted by both, with
+//	the number of records having such a value. For a table without any fields, returns one row with
+//	`nil` for the latter three.
+func StmtDescribeTable(name string) string {
+	return genStmt(cmdDescribeTable, name, nil, nil, nil)
 }
 
 //	Generates a `{"insertInto":name, "set": rec}` statement.
 func StmtInsertInto(name string, rec M) string {
-	return genStmt(cmdInsertInto, name, rec, nil)
+	return genStmt(cmdInsertInto, name, rec, nil, nil)
+}
+
+//	Generates a `{"upsertInto":name, "set": rec, "on": on}` statement.
+//
+//	If `rec` has an `__id`, updates the record with that `__id`, otherwise all records whose
+//	`on` fields equal those in `rec`. If there's no such record, inserts `rec` (under its `__id`
+//	if it has one). Following MySQL's convention, `RowsAffected` is then `1` for an insert, or
+//	`2` per updated record. `LastInsertId` is the inserted record's `__id`, or for an update the
+//	lowest numeric `__id` updated (or `-1` if none).
+func StmtUpsertInto(name string, rec M, on ...string) string {
+	return genStmt(cmdUpsertInto, name, rec, nil, M{"on": on})
 }
 
 //	Generates a `{"selectFrom":name, "where": where}` statement.
 func StmtSelectFrom(name string, where M) string {
-	return genStmt(cmdSelectFrom, name, nil, where)
+	return genStmt(cmdSelectFrom, name, nil, where, nil)
+}
+
+//	Generates a `{"selectFrom":name, "where": where, "fields": fields, "exclude": exclude}` statement.
+//
+//	If `fields` are specified, the resulting rows have exactly those columns, in that order.
+//	Otherwise, they're made up of `__id` and (sorted by name) every field found in any of the matching records.
+//	Either way, columns named in `exclude` are omitted.
+func StmtSelectFromFields(name string, where M, fields, exclude []string) string {
+	return genStmt(cmdSelectFrom, name, nil, where, M{"fields": fields, "exclude": exclude})
+}
+
+//	Generates a `{"selectFrom":name, "where": where, "orderBy": orderBy, "limit": limit, "offset": offset}` statement.
+//
+//	Each `orderBy` entry is a field name, optionally followed by ` asc` or ` desc`
+//	(such as `"Total desc"`). Records lacking such a field always come last. Ties,
+//	and all records if no `orderBy` is given, are ordered by their numeric `__id`.
+//	A `limit` or `offset` of `0` means none.
+func StmtSelectFromOrdered(name string, where M, orderBy []string, limit, offset int) string {
+	return genStmt(cmdSelectFrom, name, nil, where, M{"orderBy": orderBy, "limit": limit, "offset": offset})
+}
+
+//	Generates a `{"selectFrom":name, "where": where, "join": joins}` statement.
+//
+//	Each join is of the form `{"table": "Customers", "local": "Customer", "foreign": "__id", "as": "Cust", "left": true}`:
+//	for each record matching `where`, looks up all records in `table` whose `foreign` field (default: `__id`) equals the
+//	`local` field (which may also be `__id`, or hold a slice of values to look up) and returns one row per match, with the
+//	joined record's fields (including its `__id`) in columns prefixed by `as` (default: `table`) and `.`, such as `Cust.City`.
+//	Records without any match are omitted unless `left` is `true`. Multiple joins apply in order, so later ones may use
+//	earlier ones' prefixed columns as their `local` field.
+func StmtSelectFromJoin(name string, where M, joins ...M) string {
+	return genStmt(cmdSelectFrom, name, nil, where, M{"join": joins})
+}
+
+//	Generates a `{"selectFrom":name, "where": where, "distinct": fields}` statement.
+//
+//	The resulting rows have exactly the `fields` as columns, one row per unique combination of their
+//	values in the records matching `where`, sorted by those. Slice values are flattened: a record
+//	contributes one combination per element (and for multiple slice-valued `fields`, per combination
+//	of elements), or none for an empty slice. Cannot be combined with a `fields` clause.
+func StmtSelectFromDistinct(name string, where M, fields ...string) string {
+	return genStmt(cmdSelectFrom, name, nil, where, M{"distinct": fields})
+}
+
+//	Generates a `{"aggregate":name, "where": where, "groupBy": groupBy, "aggregates": aggregates}` statement.
+//
+//	Each of the `aggregates` is of the form `fn(field)` or `fn(field) as name`, with `fn` being one of
+//	`count`, `sum`, `min`, `max` or `avg` (and `count(*)` counting all records). The resulting rows have
+//	one column per `groupBy` field (in that order) and one per aggregate (named either by its `as name`
+//	or else its full `fn(field)` expression), sorted by the `groupBy` values. Without `groupBy`, all
+//	records matching `where` make up a single group and a single result row.
+func StmtAggregate(name string, where M, groupBy []string, aggregates ...string) string {
+	return genStmt(cmdAggregate, name, nil, where, M{"groupBy": groupBy, "aggregates": aggregates})
+}
+
+//	Generates a `{"count":name, "where": where}` statement, for use with `Query`. Its one row has
+//	one column, `count`: the number of records matching `where`.
+func StmtCount(name string, where M) string {
+	return genStmt(cmdCount, name, nil, where, nil)
+}
+
+//	Generates an `{"exists":name, "where": where}` statement, for use with `Query`. Its one row has
+//	one column, `exists`: whether any record matches `where`.
+func StmtExists(name string, where M) string {
+	return genStmt(cmdExists, name, nil, where, nil)
 }
 
 //	Generates a `{"deleteFrom":name, "where": where}` statement.
 func StmtDeleteFrom(name string, where M) string {
-	return genStmt(cmdDeleteFrom, name, nil, where)
+	return genStmt(cmdDeleteFrom, name, nil, where, nil)
 }
 
 //	Generates a `{"updateWhere":name, "set": set, "where": where}` statement.
+//
+//	Besides plain field values, `set` may contain these update operators, each taking an object
+//	of field names to operands: `$set`, `$inc` (adds a number), `$unset` (removes the fields),
+//	`$push` (appends the operand, or all of `{"$each": [...]}`, to a slice), `$pull` (removes all
+//	slice elements matching the operand, as per `M.Match` criteria) and `$rename` (renames the fields).
+//	They're applied under the table lock, and to either all or none of the matching records.
 func StmtUpdateWhere(name string, set, where M) string {
-	return genStmt(cmdUpdateWhere, name, set, where)
+	return genStmt(cmdUpdateWhere, name, set, where, nil)
 }
@@ -0,0 +1,53 @@
+package fsdb_test
+
+import (
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestPlaceholders(t *testing.T) {
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	execAll(t, db, fsdb.StmtCreateTable("T"),
+		fsdb.StmtInsertInto("T", fsdb.M{"Name": "$1", "Price": "$5"}),
+		fsdb.StmtInsertInto("T", fsdb.M{"Name": "$$2", "Price": 0}))
+
+	ins, err := db.Prepare(`{"insertInto": "T", "set": {"Name": "$1", "Price": {"$param": 2}, "Tag": "$$1"}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ins.Close()
+	for _, args := range [][]interface{}{{"a", 1}, {"b", 2}} {
+		if _, err = ins.Exec(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = ins.Exec("c"); err == nil {
+		t.Error("expected an error for too few arguments")
+	}
+
+	for _, test := range []struct {
+		stmt string
+		args []interface{}
+		want []row
+	}{
+		{fsdb.StmtSelectFromFields("T", fsdb.M{"Price": "$5"}, []string{"Name", "Price"}, nil), nil,
+			[]row{{"Name": "$1", "Price": "$5"}}},
+		{fsdb.StmtSelectFromFields("T", fsdb.M{"Name": []string{"$1", "$$2"}}, []string{"Name"}, nil), nil,
+			[]row{{"Name": "$1"}, {"Name": "$$2"}}},
+		{fsdb.StmtSelectFromFields("T", fsdb.M{"Tag": "$1"}, []string{"Name"}, nil), nil,
+			[]row{{"Name": "a"}, {"Name": "b"}}},
+		{fsdb.StmtSelectFromFields("T", fsdb.M{"Name": fsdb.M{"$param": 1}}, []string{"Name", "Price"}, nil), []interface{}{"b"},
+			[]row{{"Name": "b", "Price": int64(2)}}},
+		{`{"selectFrom": "T", "where": {"Price": {"$gt": "$1"}}, "fields": ["Name"]}`, []interface{}{1},
+			[]row{{"Name": "b"}}},
+	} {
+		rows, err := db.Query(test.stmt, test.args...)
+		if err != nil {
+			t.Fatalf("%s: %v", test.stmt, err)
+		}
+		expectRows(t, test.stmt, scanAll(t, rows), test.want)
+	}
+}
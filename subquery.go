@@ -0,0 +1,93 @@
+package fsdb
+
+import (
+	"database/sql/driver"
+)
+
+//	Returns `v` as a subquery (a `selectFrom` statement object with an optional `field`), or else `nil`.
+func subqueryOf(v interface{}) (sub M) {
+	if sub = m(v); sub != nil {
+		if _, isSub := sub[cmdSelectFrom]; !isSub {
+			sub = nil
+		}
+	}
+	return
+}
+
+func validateSubquery(sub M) (err error) {
+	query := M{}
+	for k, v := range sub {
+		if k != "field" {
+			query[k] = v
+		}
+	}
+	if field, isStr := sub["field"].(string); sub["field"] != nil && (!isStr || field == "") && paramIndex(sub["field"]) == 0 {
+		err = stmtErr(ErrInvalidClause, "field", "expected a field name, not %#v", sub["field"])
+	} else {
+		_, _, err = validateStmt(query)
+	}
+	return
+}
+
+//	Replaces (in place) every subquery in the `where` criteria by the slice of the values of its
+//	`field` (`__id` by default) in its result rows, evaluating nested subqueries first.
+func (me *conn) resolveSubqueries(where M) (err error) {
+	for fn, fx := range where {
+		switch fn {
+		case opAnd, opOr, opNot:
+			for _, sub := range interfaces(fx) {
+				if err = me.resolveSubqueries(m(sub)); err != nil {
+					return
+				}
+			}
+		default:
+			if sub := subqueryOf(fx); sub != nil {
+				where[fn], err = me.subquery(sub)
+			} else if fops := ops(fx); fops != nil {
+				for op, ov := range fops {
+					if sub = subqueryOf(ov); sub != nil {
+						if fops[op], err = me.subquery(sub); err != nil {
+							break
+						}
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+func (me *conn) subquery(sub M) (vals []interface{}, err error) {
+	var (
+		q   *selectQuery
+		res driver.Rows
+	)
+	table, _ := sub[cmdSelectFrom].(string)
+	field, isStr := sub["field"].(string)
+	if sub["field"] == nil {
+		field, isStr = IdField, true
+	}
+	if table == "" {
+		err = stmtErr(ErrInvalidClause, cmdSelectFrom, "expected a table name, not %#v", sub[cmdSelectFrom])
+	} else if !isStr || field == "" {
+		err = stmtErr(ErrInvalidClause, "field", "expected a field name, not %#v", sub["field"])
+	} else if q, err = selectQueryOf(sub); err == nil {
+		if err = me.resolveSubqueries(q.where); err == nil {
+			if res, err = me.doSelectFrom(table, q); err == nil {
+				rows := res.(*rows)
+				vals = make([]interface{}, 0, len(rows.recs))
+				for i, rec := range rows.recs {
+					if field == IdField {
+						vals = append(vals, rows.rids[i])
+					} else {
+						vals = append(vals, rec.at(field))
+					}
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package fsdb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestSubqueries(t *testing.T) {
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	execAll(t, db, fsdb.StmtCreateTable("C"), fsdb.StmtCreateTable("O"),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Alice", "City": "Berlin"}),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Bob", "City": "London"}),
+		fsdb.StmtInsertInto("C", fsdb.M{"Name": "Carl", "City": "Berlin"}),
+		fsdb.StmtInsertInto("O", fsdb.M{"C": "0", "Sku": "a"}),
+		fsdb.StmtInsertInto("O", fsdb.M{"C": "1", "Sku": "b"}),
+		fsdb.StmtInsertInto("O", fsdb.M{"C": "2", "Sku": "b"}))
+
+	for _, test := range []struct {
+		stmt    string
+		args    []interface{}
+		want    []row
+		wantErr error
+	}{
+		{stmt: `{"selectFrom": "O", "fields": ["Sku"], "where": {"C": {"selectFrom": "C", "where": {"City": "Berlin"}}}}`,
+			want: []row{{"Sku": "a"}, {"Sku": "b"}}},
+		{stmt: `{"selectFrom": "O", "fields": ["Sku"], "where": {"C": {"$nin": {"selectFrom": "C", "where": {"City": "Berlin"}}}}}`,
+			want: []row{{"Sku": "b"}}},
+		{stmt: `{"selectFrom": "C", "fields": ["Name"], "where": {"__id": {"selectFrom": "O", "field": "C", "where": {"Sku": "$1"}}}}`, args: []interface{}{"b"},
+			want: []row{{"Name": "Bob"}, {"Name": "Carl"}}},
+		{stmt: `{"selectFrom": "C", "fields": ["Name"], "where": {"City": {"selectFrom": "C", "field": "$1", "where": {"Name": "Bob"}}}}`, args: []interface{}{"City"},
+			want: []row{{"Name": "Bob"}}},
+		{stmt: `{"selectFrom": "O", "fields": ["Sku"], "where": {"$or": [{"Sku": "a"}, {"C": {"selectFrom": "C", "where": {"__id": {"selectFrom": "O", "field": "C", "where": {"Sku": "nope"}}}}}]}}`,
+			want: []row{{"Sku": "a"}}},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"selectFrom": "$1"}}}`, args: []interface{}{int64(5)}, wantErr: fsdb.ErrInvalidClause},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"$in": {"selectFrom": {"$param": 1}}}}}`, args: []interface{}{"C"}, wantErr: fsdb.ErrInvalidClause},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"selectFrom": "C", "field": "$1"}}}`, args: []interface{}{int64(5)}, wantErr: fsdb.ErrInvalidClause},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"selectFrom": "C", "field": ""}}}`, wantErr: fsdb.ErrInvalidClause},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"selectFrom": "C", "limit": "x"}}}`, wantErr: fsdb.ErrInvalidClause},
+		{stmt: `{"selectFrom": "O", "where": {"C": {"selectFrom": "Nope"}}}`, wantErr: fsdb.ErrNoSuchTable},
+	} {
+		rows, err := db.Query(test.stmt, test.args...)
+		if test.wantErr != nil {
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("%s: expected %v, got %v", test.stmt, test.wantErr, err)
+			}
+			if err == nil {
+				rows.Close()
+			}
+		} else if err != nil {
+			t.Errorf("%s: %v", test.stmt, err)
+		} else {
+			expectRows(t, test.stmt, scanAll(t, rows), test.want)
+		}
+	}
+}
@@ -3,9 +3,9 @@ package fsdb
 import (
 	"io/ioutil"
 	"os"
+	"strconv"
 	"time"
 
-	"github.com/metaleap/go-util/fs"
 	"github.com/metaleap/go-util/run"
 )
 
@@ -15,43 +15,83 @@ type table struct {
 	lastLoad       time.Time
 	name, filePath string
 	recs           M
+	meta           tableMeta
+	touched        map[string]bool
+	recordFiles    bool
 }
 
 func (me *table) fetch(where M) (recs map[string]M, err error) {
-	var rec M
 	recs = map[string]M{}
-	// fast map[id] pre-fetches if where has id query:
-	if idQuery := interfaces(where[IdField]); len(idQuery) > 0 {
+	me.each(where, func(rid string, rec M) bool {
+		recs[rid] = rec
+		return true
+	})
+	return
+}
+
+//	Calls `on` for every record matching `where`, until it returns `false`.
+//	Callers lock `me` as necessary.
+func (me *table) each(where M, on func(string, M) bool) {
+	var rec M
+	// fast map[id] pre-fetches if where has id query (other than an operator object):
+	if idQuery := interfaces(where[IdField]); len(idQuery) > 0 && ops(where[IdField]) == nil {
 		var ok bool
 		var str string
 		for _, id := range idQuery {
 			if str, ok = id.(string); ok {
-				if rec = m(me.recs[str]); rec != nil && rec.Match("", where, StrCmp) {
-					recs[str] = rec
+				if rec = m(me.recs[str]); rec != nil && rec.Match(str, where, StrCmp) && !on(str, rec) {
+					return
 				}
 			}
 		}
 	} else {
 		for rid, rix := range me.recs {
 			if rec = m(rix); rec != nil {
-				if rec.Match(rid, where, StrCmp) {
-					recs[rid] = rec
+				if rec.Match(rid, where, StrCmp) && !on(rid, rec) {
+					return
 				}
 			}
 		}
 	}
+}
+
+//	Counts the records matching `where`, but stops at `max` if positive.
+func (me *table) count(where M, max int64) (num int64) {
+	defer me.UnlockIf(me.LockIf(me.shouldLock()))
+	me.each(where, func(string, M) bool {
+		num++
+		return max <= 0 || num < max
+	})
+	return
+}
+
+//	Like `fetch`, but locks `me` as necessary.
+func (me *table) find(where M) (recs map[string]M, err error) {
+	defer me.UnlockIf(me.LockIf(me.shouldLock()))
+	recs, err = me.fetch(where)
 	return
 }
 
+//	(Re)loads `me.recs` from the table file, then replays its journal (if any) on top.
+//	If `lazy`, only does so if either file was modified since the last load (outside transactions).
+//	For tables stored as one file per record, that's the table directory, whose modification time
+//	changes whenever record files are added, removed or replaced (as `persist` does): see `reloadRecordFiles`.
 func (me *table) reload(lazy bool) (err error) {
 	var fi os.FileInfo
 	defer me.UnlockIf(me.LockIf(me.shouldLock()))
-	if fi, err = os.Stat(me.filePath); err == nil && ((!lazy) || me.recs == nil || me.lastLoad.UnixNano() == 0 || (me.conn.tx == nil && fi.ModTime().UnixNano() > me.lastLoad.UnixNano())) {
+	if fi, err = os.Stat(me.filePath); err == nil && fi.IsDir() {
+		if me.recordFiles = true; (!lazy) || me.recs == nil || me.lastLoad.UnixNano() == 0 || (me.conn.tx == nil && fi.ModTime().UnixNano() > me.lastLoad.UnixNano()) {
+			err = me.reloadRecordFiles((!lazy) || me.recs == nil)
+		}
+	} else if err == nil && ((!lazy) || me.recs == nil || me.lastLoad.UnixNano() == 0 || (me.conn.tx == nil && (fi.ModTime().UnixNano() > me.lastLoad.UnixNano() || me.journalModTime().UnixNano() > me.lastLoad.UnixNano()))) {
 		var raw []byte
 		if raw, err = ioutil.ReadFile(me.filePath); err == nil {
 			recs := M{}
 			if err = me.conn.drv.unmarshal(raw, &recs); err == nil {
-				me.recs, me.lastLoad = recs, time.Now()
+				if err = replayJournal(me.filePath, recs); err == nil {
+					me.recs, me.touched, me.lastLoad = recs, nil, time.Now()
+					err = me.loadMeta()
+				}
 			}
 		}
 	}
@@ -62,12 +102,16 @@ func (me *table) delete(recIDs []string) (res *result, err error) {
 	var (
 		num int64
 		ok  bool
+		rix interface{}
 	)
+	deleted := map[string]M{}
 	if err = me.reload(true); err == nil && len(recIDs) > 0 {
 		defer me.UnlockIf(me.LockIf(me.shouldLock()))
 		for _, rid := range recIDs {
-			if _, ok = me.recs[rid]; ok {
+			if rix, ok = me.recs[rid]; ok {
 				delete(me.recs, rid)
+				me.touch(rid)
+				deleted[rid] = m(rix)
 				num++
 			}
 		}
@@ -76,39 +120,240 @@ func (me *table) delete(recIDs []string) (res *result, err error) {
 		}
 	}
 	if err == nil {
-		res = &result{AffectedRows: num}
+		res = &result{AffectedRows: num, recs: deleted}
 	}
 	return
 }
 
+//	Inserts `rec` under its `__id` if it has one (which is then removed from `rec`), else under a new one.
 func (me *table) insert(rec M) (res *result, err error) {
 	if rec == nil {
 		err = errf("Cannot insert nil")
 	} else if err = me.reload(true); err == nil {
 		defer me.UnlockIf(me.LockIf(me.shouldLock()))
-		id := int64(len(me.recs))
-		sid := strf("%v", id)
-		if _, ok := me.recs[sid]; ok {
-			err = errf("Cannot insert: duplicate record ID")
+		var sid string
+		if rid, ok := rec[IdField]; ok {
+			ins := make(M, len(rec)-1)
+			for fn, fv := range rec {
+				if fn != IdField {
+					ins[fn] = fv
+				}
+			}
+			sid, rec = strf("%v", rid), ins
+		}
+		res, err = me.insertRec(rec, sid)
+	}
+	return
+}
+
+//	Inserts `rec` as-is under the specified `sid`, or if empty, under a new ID.
+//	`InsertedLast` is `-1` unless that ID is numeric.
+//	Callers `reload` and lock `me` as necessary.
+func (me *table) insertRec(rec M, sid string) (res *result, err error) {
+	if sid == "" {
+		if sid, err = me.newId(); err != nil {
+			return
+		}
+	}
+	id, e := strconv.ParseInt(sid, 10, 64)
+	if e != nil {
+		id = -1
+	}
+	if _, ok := me.recs[sid]; ok {
+		err = errf("Cannot insert: duplicate record ID '%s'", sid)
+	} else if me.recordFiles && !isFileName(sid) {
+		err = errf("Cannot insert: record ID '%s' is not a valid file name", sid)
+	} else {
+		me.recs[sid] = rec
+		me.touch(sid)
+		if id >= me.meta.NextId {
+			me.meta.NextId, me.meta.dirty = id+1, true
+		}
+		if err = me.persist(); err == nil {
+			res = &result{AffectedRows: 1, InsertedLast: id, recs: map[string]M{sid: rec}}
 		} else {
-			me.recs[sid] = rec
-			if err = me.persist(); err == nil {
-				res = &result{AffectedRows: 1, InsertedLast: id}
+			delete(me.recs, sid)
+		}
+	}
+	return
+}
+
+func (me *table) update(set, where M) (res *result, err error) {
+	var (
+		num  int64
+		recs map[string]M
+	)
+	if len(set) > 0 {
+		if err = me.reload(true); err == nil {
+			defer me.UnlockIf(me.LockIf(me.shouldLock()))
+			if recs, err = me.fetch(where); err == nil {
+				if num, err = me.updateRecs(recs, set); err == nil && num > 0 {
+					err = me.persist()
+				}
+			}
+		}
+	}
+	if err == nil {
+		res = &result{AffectedRows: num, recs: recs}
+	}
+	return
+}
+
+//	Applies `set` to all `recs` (see `applySet`), or if that fails for any of them, to none.
+//	Callers `reload` and lock `me` as necessary, then `persist`.
+func (me *table) updateRecs(recs map[string]M, set M) (num int64, err error) {
+	upds := make(map[string]M, len(recs))
+	for rid, rec := range recs {
+		if upds[rid], err = applySet(rec, set); err != nil {
+			return
+		}
+	}
+	for rid, upd := range upds {
+		me.recs[rid], recs[rid] = upd, upd
+		me.touch(rid)
+		num++
+	}
+	return
+}
+
+//	Updates all records matching `rec` by its `__id` if it has one, else by its `on`
+//	fields. If there are none, inserts `rec` (under its `__id` if it has one).
+func (me *table) upsert(rec M, on []string) (res *result, err error) {
+	where := M{}
+	if rid, ok := rec[IdField]; ok {
+		where[IdField] = strf("%v", rid)
+	} else {
+		for _, fn := range on {
+			fv, isSet := getPath(rec, fn)
+			if !isSet {
+				err = stmtErr(ErrInvalidClause, "on", "field '%s' is not in `set`, so would never match", fn)
+				return
+			}
+			where[fn] = fv
+		}
+	}
+	if len(where) == 0 {
+		err = errf("Cannot upsert: need either `%s` in `set` or one or more `on` fields", IdField)
+	} else if err = me.reload(true); err == nil {
+		defer me.UnlockIf(me.LockIf(me.shouldLock()))
+		var recs map[string]M
+		if recs, err = me.fetch(where); err == nil {
+			if len(recs) == 0 {
+				var (
+					sid string
+					ins M
+				)
+				if where[IdField] != nil {
+					sid = where[IdField].(string)
+				}
+				if ins, err = applySet(M{}, rec); err == nil {
+					res, err = me.insertRec(ins, sid)
+				}
 			} else {
-				delete(me.recs, sid)
+				var num int64
+				lowest := int64(-1)
+				for rid, _ := range recs {
+					if id, e := strconv.ParseInt(rid, 10, 64); e == nil && (lowest < 0 || id < lowest) {
+						lowest = id
+					}
+				}
+				if num, err = me.updateRecs(recs, rec); err == nil {
+					if err = me.persist(); err == nil {
+						res = &result{AffectedRows: 2 * num, InsertedLast: lowest}
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+func (me *table) truncate() (res *result, err error) {
+	if err = me.reload(true); err == nil {
+		defer me.UnlockIf(me.LockIf(me.shouldLock()))
+		num := int64(len(me.recs))
+		for rid, _ := range me.recs {
+			me.touch(rid)
+		}
+		me.recs = M{}
+		if num > 0 {
+			err = me.persist()
+		}
+		if err == nil {
+			res = &result{AffectedRows: num}
+		}
+	}
+	return
+}
+
+//	Renames, then drops, then adds (where missing, with their default values) the specified fields
+//	in all records, or if that fails for any of them, in none.
+func (me *table) alter(renameFields M, dropFields []string, addFields M) (res *result, err error) {
+	if err = me.reload(true); err == nil {
+		defer me.UnlockIf(me.LockIf(me.shouldLock()))
+		var num int64
+		upds := map[string]M{}
+		for rid, rix := range me.recs {
+			rec, upd, changed := m(rix), M{}, false
+			for fn, fv := range rec {
+				upd[fn] = fv
+			}
+			for oldName, newName := range renameFields {
+				if fv, ok := getPath(upd, oldName); ok {
+					unsetPath(upd, oldName)
+					if err = setPath(upd, strf("%v", newName), fv); err != nil {
+						return
+					}
+					changed = true
+				}
 			}
+			for _, fn := range dropFields {
+				if _, ok := getPath(upd, fn); ok {
+					unsetPath(upd, fn)
+					changed = true
+				}
+			}
+			for fn, fv := range addFields {
+				if _, ok := getPath(upd, fn); !ok {
+					if err = setPath(upd, fn, fv); err != nil {
+						return
+					}
+					changed = true
+				}
+			}
+			if changed {
+				upds[rid] = upd
+			}
+		}
+		for rid, upd := range upds {
+			me.recs[rid] = upd
+			me.touch(rid)
+			num++
+		}
+		if num > 0 {
+			err = me.persist()
+		}
+		if err == nil {
+			res = &result{AffectedRows: num}
 		}
 	}
 	return
 }
 
+//	Writes the changes since the last `persist` either to the changed record files (if `me` is stored
+//	as one file per record), to the journal (if enabled for `me`, see `TableOptions`) or else to the
+//	whole table file. Inside transactions, defers to `tx.Commit`.
 func (me *table) persist() (err error) {
 	if me.conn.tx == nil {
-		var raw []byte
-		if raw, err = me.conn.drv.marshal(me.recs); err == nil {
-			if err = ufs.WriteBinaryFile(me.filePath, raw); err == nil {
-				me.lastLoad = time.Now()
-			}
+		if opts := me.conn.drv.tableOptions(me.name); me.recordFiles {
+			err = me.persistRecordFiles()
+		} else if opts.Journal {
+			err = me.appendJournal(opts)
+		} else {
+			err = me.compact()
+		}
+		if err == nil && me.meta.dirty {
+			err = me.persistMeta()
 		}
 	} else {
 		me.conn.tx.tables[me] = true
@@ -0,0 +1,51 @@
+package fsdb_test
+
+import (
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestUpsertInto(t *testing.T) {
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	execAll(t, db, fsdb.StmtCreateTable("T"), fsdb.StmtInsertInto("T", fsdb.M{"Sku": "a", "Qty": 1}))
+
+	for _, test := range []struct {
+		stmt                 string
+		affected, lastInsert int64
+		wantErr              bool
+		want                 []row
+	}{
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"Sku": "a", "Qty": 2}, "Sku"), affected: 2, lastInsert: 0,
+			want: []row{{"Sku": "a", "Qty": 2.0}}},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"Sku": "b", "Qty": 3}, "Sku"), affected: 1, lastInsert: 1,
+			want: []row{{"Sku": "a", "Qty": 2.0}, {"Sku": "b", "Qty": 3.0}}},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"__id": 1, "Qty": 4}), affected: 2, lastInsert: 1,
+			want: []row{{"Sku": "a", "Qty": 2.0}, {"Sku": "b", "Qty": 4.0}}},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"__id": 7, "Sku": "c"}), affected: 1, lastInsert: 7,
+			want: []row{{"Sku": "a", "Qty": 2.0}, {"Sku": "b", "Qty": 4.0}, {"Sku": "c", "Qty": nil}}},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"Qty": 5}), wantErr: true},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"Qty": 5}, "Sku"), wantErr: true},
+		{stmt: fsdb.StmtUpsertInto("T", fsdb.M{"Sku": "a", "Qty": 5}, "Sku", "Color"), wantErr: true},
+	} {
+		res, err := db.Exec(test.stmt)
+		if err != nil || test.wantErr {
+			if (err != nil) != test.wantErr {
+				t.Errorf("%s: expected error %v, got %v", test.stmt, test.wantErr, err)
+			}
+		} else if affected, _ := res.RowsAffected(); affected != test.affected {
+			t.Errorf("%s: expected %d rows affected, got %d", test.stmt, test.affected, affected)
+		} else if lastInsert, _ := res.LastInsertId(); lastInsert != test.lastInsert {
+			t.Errorf("%s: expected last insert ID %d, got %d", test.stmt, test.lastInsert, lastInsert)
+		}
+		if rows, err := db.Query(fsdb.StmtSelectFromFields("T", nil, []string{"Sku", "Qty"}, nil)); err != nil {
+			t.Fatal(err)
+		} else if all := scanAll(t, rows); test.want != nil {
+			expectRows(t, test.stmt, all, test.want)
+		} else if len(all) != 3 {
+			t.Errorf("%s: expected no new records, got %v", test.stmt, all)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package fsdb
 
 import (
+	"os"
 	"path/filepath"
 
+	"github.com/metaleap/go-util/fs"
 	"github.com/metaleap/go-util/run"
 	"github.com/metaleap/go-util/slice"
 )
@@ -16,10 +18,14 @@ type tables struct {
 func (me *tables) init(conn *conn, close bool) (err error) {
 	me.conn, me.all = conn, map[string]*table{}
 	if !close {
+		var tempFiles []string
 		tableNames, errs := conn.enumTableFiles()
 		if len(errs) > 0 {
 			err = errs[0]
-		} else {
+		} else if tempFiles, err = conn.enumTempFiles(); err == nil {
+			if len(tempFiles) > 0 && OnLeftoverTempFiles != nil {
+				OnLeftoverTempFiles(&TempFilesError{Paths: tempFiles})
+			}
 			for _, tn := range tableNames {
 				if _, err = me.get(tn); err != nil {
 					break
@@ -37,7 +43,41 @@ func (me *tables) get(name string) (t *table, err error) {
 		if err = t.reload(true); err == nil {
 			me.all[t.name] = t
 		} else {
-			t = nil
+			if t = nil; os.IsNotExist(err) {
+				err = stmtErr(ErrNoSuchTable, name, "")
+			}
+		}
+	}
+	return
+}
+
+//	Renames the table `name` to `newName`, both on disk and in `me.all`.
+func (me *tables) rename(name, newName string) (err error) {
+	var t *table
+	if t, err = me.get(name); err == nil {
+		defer me.UnlockIf(me.LockIf(me.shouldLock()))
+		newPath := filepath.Join(me.conn.dir, newName+me.conn.drv.fileExt)
+		if _, exists := me.all[newName]; exists || ufs.FileExists(newPath) || ufs.DirExists(newPath) {
+			err = errf("Cannot rename table '%s' to '%s': already exists", name, newName)
+		} else {
+			// the table file first, then its sidecars: if any rename fails, the preceding ones are undone
+			oldPaths, newPaths := append([]string{t.filePath}, sidecarFilePaths(t.filePath)...), append([]string{newPath}, sidecarFilePaths(newPath)...)
+			for i := 0; i < len(oldPaths) && err == nil; i++ {
+				if i == 0 || ufs.FileExists(oldPaths[i]) {
+					if err = os.Rename(oldPaths[i], newPaths[i]); err != nil {
+						for i--; i >= 0; i-- {
+							if ufs.FileExists(newPaths[i]) || ufs.DirExists(newPaths[i]) {
+								os.Rename(newPaths[i], oldPaths[i])
+							}
+						}
+					}
+				}
+			}
+			if err == nil {
+				delete(me.all, name)
+				t.name, t.filePath = newName, newPath
+				me.all[newName] = t
+			}
 		}
 	}
 	return
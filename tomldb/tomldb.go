@@ -5,6 +5,11 @@ package tomldb
 
 import (
 	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/go-forks/toml"
 	"github.com/metaleap/go-fsdb"
@@ -20,52 +25,102 @@ var (
 	FileExt = ".tomldbt"
 )
 
-//	Returns a `fsdb.NewDriver` initialized with `FileExt` and TOML un/marshalers.
-func NewDriver(connectionCaching bool) driver.Driver {
+//	Returns a `fsdb.NewDriver` initialized with `FileExt` and TOML un/marshalers, and `opts` (if any).
+func NewDriver(connectionCaching bool, opts ...fsdb.Options) driver.Driver {
 	tomlUnmarshal := func(data []byte, v interface{}) (err error) {
 		_, err = toml.Decode(string(data), v)
 		return
 	}
 	tomlMarshal := func(v interface{}) (data []byte, err error) {
-		var (
-			buf     ustr.Buffer
-			m, rec  map[string]interface{}
-			sl      []interface{}
-			fx, rx  interface{}
-			ok      bool
-			fn, rid string
-			i       int
-		)
-		if m, ok = v.(map[string]interface{}); m == nil || !ok {
-			m, ok = v.(fsdb.M)
+		var buf ustr.Buffer
+		if m := tomlTable(v); m != nil {
+			writeTable(&buf, "", m)
 		}
-		if m != nil && ok {
-			for rid, rx = range m {
-				buf.Writeln("[%v]", rid)
-				if rec, ok = rx.(map[string]interface{}); rec == nil || !ok {
-					rec, ok = rx.(fsdb.M)
-				}
-				if rec != nil && ok {
-					for fn, fx = range rec {
-						if sl, ok = fx.([]interface{}); ok {
-							buf.Write("%v = [", fn)
-							for i, fx = range sl {
-								if i > 0 {
-									buf.Write(", ")
-								}
-								buf.Write("%#v", fx)
-							}
-							buf.Writeln("]")
-						} else {
-							buf.Writeln("%v = %#v", fn, fx)
-						}
-					}
-				}
+		data = buf.Bytes()
+		return
+	}
+	return fsdb.NewDriver(FileExt, connectionCaching, tomlMarshal, tomlUnmarshal, opts...)
+}
+
+var bareKey = regexp.MustCompile("^[A-Za-z0-9_-]+$")
+
+//	Writes `m` as TOML: first its non-object values as `key = value` lines, then its objects
+//	as `[path.key]` sub-tables (recursively), each in `fsdb.SortedKeys` order. So a whole data
+//	table is written as one sub-table per record (named by its `__id`), and a single record as
+//	a flat list of its fields.
+func writeTable(buf *ustr.Buffer, path string, m map[string]interface{}) {
+	keys := fsdb.SortedKeys(m)
+	for _, fn := range keys {
+		if fv := m[fn]; fv != nil && tomlTable(fv) == nil {
+			buf.Writeln("%s = %s", tomlKey(fn), tomlValue(fv))
+		}
+	}
+	for _, fn := range keys {
+		if sub := tomlTable(m[fn]); sub != nil {
+			subPath := tomlKey(fn)
+			if path != "" {
+				subPath = path + "." + subPath
+			}
+			if buf.Len() > 0 {
 				buf.Writeln("")
 			}
+			buf.Writeln("[%s]", subPath)
+			writeTable(buf, subPath, sub)
 		}
-		data = buf.Bytes()
-		return
 	}
-	return fsdb.NewDriver(FileExt, connectionCaching, tomlMarshal, tomlUnmarshal)
+}
+
+func tomlTable(v interface{}) (m map[string]interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m = t
+	case fsdb.M:
+		m = t
+	}
+	return
+}
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func tomlValue(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return strconv.Quote(t)
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf("%v", t)
+	case time.Time:
+		return t.Format(time.RFC3339Nano)
+	}
+	if m := tomlTable(v); m != nil {
+		var buf ustr.Buffer
+		buf.Write("{")
+		for _, fn := range fsdb.SortedKeys(m) {
+			if fv := m[fn]; fv != nil {
+				if buf.Len() > 1 {
+					buf.Write(", ")
+				}
+				buf.Write("%s = %s", tomlKey(fn), tomlValue(fv))
+			}
+		}
+		buf.Write("}")
+		return buf.String()
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array {
+		var buf ustr.Buffer
+		buf.Write("[")
+		for i := 0; i < rv.Len(); i++ {
+			if i > 0 {
+				buf.Write(", ")
+			}
+			buf.Write("%s", tomlValue(rv.Index(i).Interface()))
+		}
+		buf.Write("]")
+		return buf.String()
+	}
+	return strconv.Quote(fmt.Sprintf("%v", v))
 }
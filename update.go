@@ -0,0 +1,118 @@
+package fsdb
+
+const (
+	opSet    = "$set"
+	opInc    = "$inc"
+	opUnset  = "$unset"
+	opPush   = "$push"
+	opPull   = "$pull"
+	opRename = "$rename"
+	opEach   = "$each"
+)
+
+//	Returns a copy of `rec` with `set` applied: plain fields are overwritten, while
+//	these update operators each take an object of field names to operands:
+//
+//	- `$set`: overwrites fields, just like plain fields do.
+//
+//	- `$inc`: adds the (possibly negative) number to the field's number (or to `0` if missing).
+//
+//	- `$unset`: removes the fields (operands are ignored, and a slice of field names may be given instead).
+//
+//	- `$push`: appends the value to the field's slice (creating it if missing), or all values of `{"$each": [...]}`.
+//
+//	- `$pull`: removes all elements from the field's slice that match the operand, as per `M.Match` criteria.
+//
+//	- `$rename`: renames the fields to the operand names.
+func applySet(rec, set M) (upd M, err error) {
+	upd = make(M, len(rec))
+	for fn, fv := range rec {
+		upd[fn] = fv
+	}
+	for fn, fv := range set {
+		switch fn {
+		case IdField:
+		case opSet, opInc, opPush, opPull, opRename:
+			if operands := m(fv); operands == nil {
+				err = errf("Invalid '%s': expected an object of field names to operands, not %v", fn, fv)
+			} else {
+				for ofn, ov := range operands {
+					if err = applyOp(upd, fn, ofn, ov); err != nil {
+						break
+					}
+				}
+			}
+		case opUnset:
+			if operands := m(fv); operands != nil {
+				for ofn, _ := range operands {
+					unsetPath(upd, ofn)
+				}
+			} else {
+				for _, ofn := range strs(fv) {
+					unsetPath(upd, ofn)
+				}
+			}
+		default:
+			err = setPath(upd, fn, fv)
+		}
+		if err != nil {
+			upd = nil
+			break
+		}
+	}
+	return
+}
+
+func applyOp(rec M, op, fn string, operand interface{}) (err error) {
+	cur, exists := getPath(rec, fn)
+	switch op {
+	case opSet:
+		err = setPath(rec, fn, operand)
+	case opInc:
+		inc, ok := number(operand)
+		if !ok {
+			err = errf("Cannot %s '%s' by non-number %v", op, fn, operand)
+		} else if !exists || cur == nil {
+			err = setPath(rec, fn, operand)
+		} else if ci, cok := cur.(int64); cok {
+			if ii, iok := operand.(int64); iok {
+				err = setPath(rec, fn, ci+ii)
+			} else {
+				err = setPath(rec, fn, float64(ci)+inc)
+			}
+		} else if cf, cok := number(cur); cok {
+			err = setPath(rec, fn, cf+inc)
+		} else {
+			err = errf("Cannot %s non-number field '%s'", op, fn)
+		}
+	case opPush:
+		sl, isSlice := cur.([]interface{})
+		if exists && cur != nil && !isSlice {
+			err = errf("Cannot %s to non-slice field '%s'", op, fn)
+		} else {
+			vals := []interface{}{operand}
+			if each := m(operand); each != nil && each[opEach] != nil {
+				vals = interfaces(each[opEach])
+			}
+			err = setPath(rec, fn, append(append(make([]interface{}, 0, len(sl)+len(vals)), sl...), vals...))
+		}
+	case opPull:
+		if sl, isSlice := cur.([]interface{}); isSlice {
+			kept := make([]interface{}, 0, len(sl))
+			for _, v := range sl {
+				if !matchValue(v, operand, StrCmp) {
+					kept = append(kept, v)
+				}
+			}
+			err = setPath(rec, fn, kept)
+		} else if exists && cur != nil {
+			err = errf("Cannot %s from non-slice field '%s'", op, fn)
+		}
+	case opRename:
+		if exists {
+			unsetPath(rec, fn)
+			err = setPath(rec, strf("%v", operand), cur)
+		}
+	}
+	return
+}
@@ -0,0 +1,211 @@
+package fsdb
+
+import (
+	"strings"
+
+	"github.com/metaleap/go-util/slice"
+)
+
+type clauseKind int
+
+const (
+	clauseObject clauseKind = iota
+	clauseObjects
+	clauseString
+	clauseStrings
+	clauseNumber
+	clauseFields
+)
+
+//	All clauses known for each command.
+var stmtClauses = map[string]map[string]clauseKind{
+	cmdCreateTable:   {"idStrategy": clauseString},
+	cmdDropTable:     {},
+	cmdRenameTable:   {"to": clauseString},
+	cmdTruncateTable: {},
+	cmdListTables:    {},
+	cmdDescribeTable: {},
+	cmdAlterTable:    {"renameFields": clauseObject, "dropFields": clauseStrings, "addFields": clauseObject},
+	cmdInsertInto:    {"set": clauseObject, "returning": clauseFields},
+	cmdSelectFrom: {"where": clauseObject, "fields": clauseStrings, "exclude": clauseStrings, "orderBy": clauseStrings,
+		"limit": clauseNumber, "offset": clauseNumber, "join": clauseObjects, "distinct": clauseStrings},
+	cmdUpdateWhere: {"set": clauseObject, "where": clauseObject, "returning": clauseFields},
+	cmdDeleteFrom:  {"where": clauseObject, "returning": clauseFields},
+	cmdCount:       {"where": clauseObject},
+	cmdExists:      {"where": clauseObject},
+	cmdAggregate:   {"where": clauseObject, "groupBy": clauseStrings, "aggregates": clauseStrings},
+	cmdUpsertInto:  {"set": clauseObject, "on": clauseStrings},
+}
+
+//	All operators known in `where` criteria, other than `opAnd`, `opOr` and `opNot`.
+var filterOps = []string{opEq, opNe, opGt, opGte, opLt, opLte, opIn, opNin, opRegex, opLike, opPrefix, opContains, opOptions}
+
+//	All operators known in `set`.
+var setOps = []string{opSet, opInc, opUnset, opPush, opPull, opRename}
+
+//	Returns the one command key in `query` and its table name, or else the first reason `query` is invalid.
+//	Clauses whose value is `null` are treated as absent.
+func validateStmt(query M) (cmd, table string, err error) {
+	for k, v := range query {
+		if _, isCmd := stmtClauses[k]; isCmd {
+			if cmd != "" {
+				return "", "", stmtErr(ErrUnknownCommand, k, "statement already has command '%s'", cmd)
+			} else if table, _ = v.(string); table == "" || (k != cmdListTables && !isFileName(table)) {
+				return "", "", stmtErr(ErrInvalidClause, k, "expected a table name, not %#v", v)
+			}
+			cmd = k
+		}
+	}
+	if cmd == "" {
+		keys := make([]string, 0, len(query))
+		for k, _ := range query {
+			keys = append(keys, k)
+		}
+		return "", "", stmtErr(ErrUnknownCommand, strings.Join(keys, ","), "")
+	}
+	for k, v := range query {
+		if kind, known := stmtClauses[cmd][k]; k == cmd || v == nil {
+			continue
+		} else if !known {
+			if _, known = clauseKinds()[k]; known {
+				err = stmtErr(ErrUnknownClause, k, "not a clause of '%s'", cmd)
+			} else {
+				err = stmtErr(ErrUnknownClause, k, "")
+			}
+		} else if paramIndex(v) == 0 {
+			err = validateClause(k, kind, v)
+		}
+		if err != nil {
+			break
+		}
+	}
+	if to, isStr := query["to"].(string); err == nil && isStr && !isFileName(to) {
+		err = stmtErr(ErrInvalidClause, "to", "expected a table name, not %#v", to)
+	}
+	if err == nil && query["distinct"] != nil && query["fields"] != nil {
+		err = stmtErr(ErrInvalidClause, "distinct", "cannot be combined with 'fields'")
+	}
+	if err == nil && cmd == cmdAggregate {
+		_, err = newAggregates(literalStrs(query["aggregates"]), literalStrs(query["groupBy"]))
+	}
+	return
+}
+
+//	Returns whether `name` can be used as a file name on all common file systems, as table
+//	names and (for tables stored as one file per record) record IDs are: so never as a path.
+func isFileName(name string) bool {
+	return name != "" && name != "." && name != ".." && !strings.ContainsAny(name, "/\\:*?\"<>|\x00")
+}
+
+//	The strings in `v` (a string or a list of strings and placeholders), without the placeholders.
+func literalStrs(v interface{}) (ss []string) {
+	if ss, _ = v.([]string); ss == nil {
+		for _, s := range interfaces(v) {
+			if str, isStr := s.(string); isStr {
+				ss = append(ss, str)
+			}
+		}
+	}
+	return
+}
+
+func clauseKinds() (all map[string]clauseKind) {
+	all = map[string]clauseKind{}
+	for _, clauses := range stmtClauses {
+		for k, kind := range clauses {
+			all[k] = kind
+		}
+	}
+	return
+}
+
+func validateClause(key string, kind clauseKind, v interface{}) (err error) {
+	switch kind {
+	case clauseNumber:
+		if _, ok := number(v); !ok {
+			err = stmtErr(ErrInvalidClause, key, "expected a number, not %v", v)
+		}
+	case clauseString:
+		if s, ok := v.(string); !ok {
+			err = stmtErr(ErrInvalidClause, key, "expected a string, not %v", v)
+		} else if key == "idStrategy" && !uslice.StrHas(idStrategies, s) {
+			err = stmtErr(ErrInvalidClause, key, "expected one of %s, not '%s'", strings.Join(idStrategies, ", "), s)
+		}
+	case clauseFields:
+		if _, ok := v.(bool); ok {
+			break
+		}
+		fallthrough
+	case clauseStrings:
+		if _, ok := v.([]string); !ok {
+			for _, s := range interfaces(v) {
+				if _, ok = s.(string); !ok && paramIndex(s) == 0 {
+					return stmtErr(ErrInvalidClause, key, "expected a string or a list of strings, not %v", v)
+				}
+			}
+		}
+	case clauseObjects:
+		for _, o := range interfaces(v) {
+			if m(o) == nil {
+				return stmtErr(ErrInvalidClause, key, "expected an object or a list of objects, not %v", v)
+			}
+		}
+	case clauseObject:
+		if obj := m(v); obj == nil {
+			err = stmtErr(ErrInvalidClause, key, "expected an object, not %v", v)
+		} else if key == "where" {
+			err = validateFilter(obj)
+		} else if key == "set" {
+			for fn, _ := range obj {
+				if strings.HasPrefix(fn, "$") && !uslice.StrHas(setOps, fn) {
+					return stmtErr(ErrUnknownOperator, fn, "in set")
+				}
+			}
+		}
+	}
+	return
+}
+
+func validateFilter(filter M) (err error) {
+	for fn, fx := range filter {
+		switch fn {
+		case opAnd, opOr, opNot:
+			for _, sub := range interfaces(fx) {
+				if subm := m(sub); subm == nil {
+					err = stmtErr(ErrInvalidClause, fn, "expected an object or a list of objects, not %v", fx)
+				} else {
+					err = validateFilter(subm)
+				}
+				if err != nil {
+					return
+				}
+			}
+		default:
+			if strings.HasPrefix(fn, "$") {
+				return stmtErr(ErrUnknownOperator, fn, "in where")
+			} else if sub := subqueryOf(fx); sub != nil {
+				err = validateSubquery(sub)
+			} else if fops := ops(fx); fops != nil && paramIndex(fx) == 0 {
+				for op, ov := range fops {
+					if !uslice.StrHas(filterOps, op) {
+						return stmtErr(ErrUnknownOperator, op, "in where criteria for '%s'", fn)
+					} else if sub = subqueryOf(ov); sub != nil {
+						if err = validateSubquery(sub); err != nil {
+							return
+						}
+					} else if _, isStr := ov.(string); op == opOptions && !isStr && paramIndex(ov) == 0 {
+						return stmtErr(ErrInvalidClause, op, "in where criteria for '%s': expected a string, not %v", fn, ov)
+					} else if isPatternOp(op) && paramIndex(ov) == 0 {
+						if _, e := pattern(op, ov, fops[opOptions]); e != nil {
+							return stmtErr(ErrInvalidClause, op, "in where criteria for '%s': %s", fn, e.Error())
+						}
+					}
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,72 @@
+package fsdb_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/metaleap/go-fsdb"
+	"github.com/metaleap/go-fsdb/jsondb"
+)
+
+func TestValidateStmt(t *testing.T) {
+	db, dir := openTestDB(t, jsondb.NewDriver(false))
+	defer closeTestDB(db, dir)
+	execAll(t, db, fsdb.StmtCreateTable("T"), fsdb.StmtInsertInto("T", fsdb.M{"A": "x", "N": 1}))
+
+	for _, test := range []struct {
+		stmt    string
+		wantErr error
+		wantKey string
+	}{
+		{stmt: `{"selectFrom": "T", "where": {"A": {"$in": ["x", "$1"]}}, "limit": "$2"}`},
+		{stmt: `{"aggregate": "T", "groupBy": ["A"], "aggregates": ["count(*) as N", "$1"]}`},
+		{stmt: `{"selectFrom": "T", "where": {"A": "x"`, wantErr: fsdb.ErrSyntax},
+		{stmt: `{"selectFrom": "T", "insertInto": "T"}`, wantErr: fsdb.ErrUnknownCommand},
+		{stmt: `{"selectForm": "T"}`, wantErr: fsdb.ErrUnknownCommand, wantKey: "selectForm"},
+		{stmt: `{"selectFrom": "T", "wehre": {}}`, wantErr: fsdb.ErrUnknownClause, wantKey: "wehre"},
+		{stmt: `{"selectFrom": "T", "set": {"A": 1}}`, wantErr: fsdb.ErrUnknownClause, wantKey: "set"},
+		{stmt: `{"selectFrom": ""}`, wantErr: fsdb.ErrInvalidClause, wantKey: "selectFrom"},
+		{stmt: `{"selectFrom": 1}`, wantErr: fsdb.ErrInvalidClause, wantKey: "selectFrom"},
+		{stmt: `{"selectFrom": "$1"}`, wantErr: fsdb.ErrInvalidClause, wantKey: "selectFrom"},
+		{stmt: `{"selectFrom": "T", "where": []}`, wantErr: fsdb.ErrInvalidClause, wantKey: "where"},
+		{stmt: `{"selectFrom": "T", "limit": "ten"}`, wantErr: fsdb.ErrInvalidClause, wantKey: "limit"},
+		{stmt: `{"selectFrom": "T", "fields": [1]}`, wantErr: fsdb.ErrInvalidClause, wantKey: "fields"},
+		{stmt: `{"selectFrom": "T", "join": [1]}`, wantErr: fsdb.ErrInvalidClause, wantKey: "join"},
+		{stmt: `{"insertInto": "T", "set": "x"}`, wantErr: fsdb.ErrInvalidClause, wantKey: "set"},
+		{stmt: `{"aggregate": "T", "aggregates": ["bad(A)"]}`, wantErr: fsdb.ErrInvalidClause, wantKey: "aggregates"},
+		{stmt: `{"aggregate": "T", "groupBy": ["A"], "aggregates": ["count(*) as A"]}`, wantErr: fsdb.ErrInvalidClause, wantKey: "aggregates"},
+		{stmt: `{"aggregate": "T", "aggregates": ["min(N) as X", "max(N) as X", "$1"]}`, wantErr: fsdb.ErrInvalidClause, wantKey: "aggregates"},
+		{stmt: `{"selectFrom": "T", "where": {"A": {"$regexp": "x"}}}`, wantErr: fsdb.ErrUnknownOperator, wantKey: "$regexp"},
+		{stmt: `{"selectFrom": "T", "where": {"$nor": []}}`, wantErr: fsdb.ErrUnknownOperator, wantKey: "$nor"},
+		{stmt: `{"updateWhere": "T", "set": {"$add": {"N": 1}}}`, wantErr: fsdb.ErrUnknownOperator, wantKey: "$add"},
+	} {
+		st, err := db.Prepare(test.stmt)
+		if err == nil {
+			st.Close()
+		}
+		var stmtErr *fsdb.StmtError
+		if test.wantErr == nil && err != nil {
+			t.Errorf("%s: expected no error, got %v", test.stmt, err)
+		} else if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: expected %v, got %v", test.stmt, test.wantErr, err)
+		} else if err != nil && test.wantKey != "" && !(errors.As(err, &stmtErr) && stmtErr.Key == test.wantKey) {
+			t.Errorf("%s: expected the error to be about '%s', got %v", test.stmt, test.wantKey, err)
+		}
+	}
+
+	for _, test := range []struct {
+		stmt    string
+		wantErr error
+	}{
+		{fsdb.StmtDeleteFrom("Nope", nil), fsdb.ErrNoSuchTable},
+		{fsdb.StmtUpdateWhere("T", fsdb.M{"A.B": 1}, nil), fsdb.ErrInvalidPath},
+		{fsdb.StmtUpsertInto("T", fsdb.M{"N": 2}, "A"), fsdb.ErrInvalidClause},
+	} {
+		if _, err := db.Exec(test.stmt); !errors.Is(err, test.wantErr) {
+			t.Errorf("%s: expected %v, got %v", test.stmt, test.wantErr, err)
+		}
+	}
+	if _, err := fsdb.Aggregate("T").GroupBy("A").Count("*", "A").Build(); !errors.Is(err, fsdb.ErrInvalidClause) {
+		t.Errorf("expected %v for a duplicate aggregate column, got %v", fsdb.ErrInvalidClause, err)
+	}
+}